@@ -1,30 +1,38 @@
 package main
 
 import (
+	"calculator/internal/cache"
 	"calculator/internal/service"
 	"calculator/internal/storage"
 	pb "calculator/pkg/calculator_pb"
-	"github.com/go-redis/redis"
 	"google.golang.org/grpc"
 	"log"
 	"net"
 	"os"
+	"strconv"
 )
 
+const defaultCacheSize = 1000
+
 func main() {
 	lis, err := net.Listen("tcp", ":8090")
 	if err != nil {
 		log.Fatalf("failed to listen: %v", err)
 	}
 
+	// without redis address calculations are cached only in memory of the process
 	redisAddres := os.Getenv("REDIS_ADDRESS")
-	if redisAddres == "" {
-		log.Fatalln("don't have redis address")
+	cacheSize := defaultCacheSize
+	if size := os.Getenv("CACHE_SIZE"); size != "" {
+		cacheSize, err = strconv.Atoi(size)
+		if err != nil || cacheSize <= 0 {
+			log.Fatalf("invalid cache size %q", size)
+		}
+	}
+	calculations, err := cache.New(os.Getenv("CACHE_MODE"), redisAddres, cacheSize)
+	if err != nil {
+		log.Fatalln(err)
 	}
-	clientRedis := redis.NewClient(&redis.Options{
-		Addr: redisAddres,
-		DB:   0,
-	})
 
 	tasks, err := storage.NewTasksMongoStorage()
 	if err != nil {
@@ -32,7 +40,7 @@ func main() {
 	}
 	defer tasks.Disconnect()
 	s := grpc.NewServer()
-	service, err := service.NewService(tasks, clientRedis)
+	service, err := service.NewService(tasks, calculations)
 
 	pb.RegisterCalculatorServer(s, service)
 	log.Printf("server listening at %v", lis.Addr())
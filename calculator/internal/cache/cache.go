@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	RedisMode  = "redis"
+	LRUMode    = "lru"
+	TieredMode = "tiered"
+)
+
+var ErrMiss = errors.New("cache miss")
+
+// values got from remote cache are kept in local one of tiered cache for this time
+const localTTL = time.Hour
+
+// Cache stores calculation results by key, Get returns ErrMiss when there is no value
+type Cache interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+// New makes cache of the mode: redis falls back to local LRU while Redis is
+// unavailable, tiered keeps recent values in local LRU in front of Redis
+func New(mode string, redisAddress string, lruSize int) (Cache, error) {
+	if mode == "" {
+		mode = LRUMode
+		if redisAddress != "" {
+			mode = TieredMode
+		}
+	}
+	if mode == LRUMode {
+		return NewLRU(lruSize), nil
+	}
+	if mode != RedisMode && mode != TieredMode {
+		return nil, fmt.Errorf("unknown cache mode %q, expected one of %v, %v, %v", mode, RedisMode, LRUMode, TieredMode)
+	}
+	if redisAddress == "" {
+		return nil, fmt.Errorf("cache mode %v needs redis address", mode)
+	}
+	return &layered{
+		local:  NewLRU(lruSize),
+		remote: NewRedis(redisAddress),
+		tiered: mode == TieredMode,
+	}, nil
+}
+
+// layered reads local cache first only when it's tiered,
+// otherwise local cache is used only when remote one fails
+type layered struct {
+	local  Cache
+	remote Cache
+	tiered bool
+}
+
+// remote errors aren't returned, remote cache reports its failures itself
+func (c *layered) Get(key string) ([]byte, error) {
+	if c.tiered {
+		if value, err := c.local.Get(key); err == nil {
+			return value, nil
+		}
+	}
+	value, err := c.remote.Get(key)
+	if err == nil {
+		if c.tiered {
+			c.local.Set(key, value, localTTL)
+		}
+		return value, nil
+	}
+	if errors.Is(err, ErrMiss) {
+		return nil, err
+	}
+	return c.local.Get(key)
+}
+
+func (c *layered) Set(key string, value []byte, ttl time.Duration) error {
+	if err := c.remote.Set(key, value, ttl); c.tiered || err != nil {
+		return c.local.Set(key, value, ttl)
+	}
+	return nil
+}
+
+// Counted counts hits and misses of the cache
+type Counted struct {
+	Cache
+	hits   uint64
+	misses uint64
+}
+
+func WithStats(c Cache) *Counted {
+	return &Counted{Cache: c}
+}
+
+func (c *Counted) Get(key string) ([]byte, error) {
+	value, err := c.Cache.Get(key)
+	if err == nil {
+		atomic.AddUint64(&c.hits, 1)
+	} else {
+		atomic.AddUint64(&c.misses, 1)
+	}
+	return value, err
+}
+
+func (c *Counted) Stats() (hits uint64, misses uint64) {
+	return atomic.LoadUint64(&c.hits), atomic.LoadUint64(&c.misses)
+}
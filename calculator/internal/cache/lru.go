@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is in-process cache which evicts the least recently used value when it's full
+type LRU struct {
+	mutex  sync.Mutex
+	size   int
+	order  *list.List
+	values map[string]*list.Element
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:   size,
+		order:  list.New(),
+		values: make(map[string]*list.Element, size),
+	}
+}
+
+func (c *LRU) Get(key string) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.values[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	e := element.Value.(*entry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		c.order.Remove(element)
+		delete(c.values, key)
+		return nil, ErrMiss
+	}
+	c.order.MoveToFront(element)
+	return e.value, nil
+}
+
+// Set stores the value, zero ttl means that it doesn't expire
+func (c *LRU) Set(key string, value []byte, ttl time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e := &entry{key: key, value: value}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	if element, ok := c.values[key]; ok {
+		element.Value = e
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.values[key] = c.order.PushFront(e)
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.values, oldest.Value.(*entry).key)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"errors"
+	"github.com/go-redis/redis"
+	"log"
+	"sync"
+	"time"
+)
+
+// after a failure Redis isn't asked again for this time, so requests don't wait for timeouts
+const redisRetryAfter = 10 * time.Second
+
+var ErrUnavailable = errors.New("redis is unavailable")
+
+// Redis cache logs once when it becomes unavailable and once when it's back
+type Redis struct {
+	client    *redis.Client
+	mutex     sync.Mutex
+	down      bool
+	downUntil time.Time
+}
+
+func NewRedis(address string) *Redis {
+	return &Redis{
+		client: redis.NewClient(&redis.Options{
+			Addr:         address,
+			DB:           0,
+			DialTimeout:  time.Second,
+			ReadTimeout:  time.Second,
+			WriteTimeout: time.Second,
+		}),
+	}
+}
+
+func (c *Redis) Get(key string) ([]byte, error) {
+	if err := c.available(); err != nil {
+		return nil, err
+	}
+	value, err := c.client.Get(key).Bytes()
+	if err == redis.Nil {
+		c.result(nil)
+		return nil, ErrMiss
+	}
+	return value, c.result(err)
+}
+
+func (c *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	if err := c.available(); err != nil {
+		return err
+	}
+	return c.result(c.client.Set(key, value, ttl).Err())
+}
+
+func (c *Redis) available() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.down && time.Now().Before(c.downUntil) {
+		return ErrUnavailable
+	}
+	return nil
+}
+
+// result marks Redis down after the error or available again after success
+func (c *Redis) result(err error) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err == nil {
+		if c.down {
+			log.Println("redis is available again")
+		}
+		c.down = false
+		return nil
+	}
+	if !c.down {
+		log.Printf("redis is unavailable due to %v, using local cache", err)
+	}
+	c.down = true
+	c.downUntil = time.Now().Add(redisRetryAfter)
+	return err
+}
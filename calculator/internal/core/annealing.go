@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// shift moves random work to random position between its last predecessor and first successor
+func shift(rng *rand.Rand, works map[WorkID]Work, sequence []WorkID) []WorkID {
+	i := rng.Intn(len(sequence))
+	moved := sequence[i]
+	rest := make([]WorkID, 0, len(sequence))
+	rest = append(rest, sequence[:i]...)
+	rest = append(rest, sequence[i+1:]...)
+
+	low, high := 0, len(rest)
+	for j, id := range rest {
+		if _, ok := works[moved].WorksNeedToBeDone[id]; ok {
+			low = j + 1
+		}
+		if _, ok := works[id].WorksNeedToBeDone[moved]; ok && j < high {
+			high = j
+		}
+	}
+	position := low + rng.Intn(high-low+1)
+
+	res := make([]WorkID, 0, len(sequence))
+	res = append(res, rest[:position]...)
+	res = append(res, moved)
+	return append(res, rest[position:]...)
+}
+
+// annealingScheduler runs simulated annealing over work sequences,
+// parameters: iterations, temperature, cooling
+type annealingScheduler struct{}
+
+func (annealingScheduler) Schedule(ctx context.Context, task *Task, opts Options) (res Result, err error) {
+	iterations, err := opts.Parameters.Int("iterations", 100*1000)
+	if err != nil {
+		return res, err
+	}
+	temperature, err := opts.Parameters.Float("temperature", 10)
+	if err != nil {
+		return res, err
+	}
+	cooling, err := opts.Parameters.Float("cooling", 0.9995)
+	if err != nil {
+		return res, err
+	}
+	if cooling >= 1 {
+		return res, fmt.Errorf("%w cooling: expected number less than 1, got %v", ErrInvalidParameter, cooling)
+	}
+	pr, err := newProblem(task)
+	if err != nil {
+		return res, err
+	}
+
+	priority, err := priorityRules["lft"](task)
+	if err != nil {
+		return res, err
+	}
+	sequence := prioritySequence(task.Works, priority)
+	current := pr.scheduleSequence(sequence)
+	res.Schedule = current
+	res.LowerBound = pr.lowerBound()
+	report := newReporter(opts)
+	report.improved(current, 0)
+	if len(sequence) < 2 {
+		res.Optimal = res.Schedule.reaches(res.LowerBound)
+		return res, nil
+	}
+	// a unit of lateness costs as much as the whole first schedule
+	lateCost := float64(current.Time + 1)
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for i := 0; i < iterations && !res.Schedule.reaches(res.LowerBound); i++ {
+		if ctx.Err() != nil {
+			res.Partial = true
+			break
+		}
+		candidate := shift(rng, task.Works, sequence)
+		schedule := pr.scheduleSequence(candidate)
+		delta := float64(schedule.Time) - float64(current.Time) +
+			lateCost*(float64(schedule.Lateness)-float64(current.Lateness))
+		if delta <= 0 || rng.Float64() < math.Exp(-delta/temperature) {
+			sequence, current = candidate, schedule
+			if current.better(res.Schedule) {
+				res.Schedule = current
+				report.improved(current, i+1)
+			}
+		}
+		temperature *= cooling
+	}
+	res.Optimal = res.Schedule.reaches(res.LowerBound)
+	return res, nil
+}
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", dayLayout}
+
+var defaultWorkingDays = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+
+// Calendar tells when the works of a task are done. A working day has UnitsPerDay
+// time units of length Unit, counted from the time of the task start date.
+// Empty Unit means that one time unit is a whole working day.
+type Calendar struct {
+	WorkingDays []string `json:"working_days" bson:"working_days"`
+	Holidays    []string `json:"holidays" bson:"holidays"`
+	Unit        string   `json:"unit" bson:"unit"`
+	UnitsPerDay uint     `json:"units_per_day" bson:"units_per_day"`
+}
+
+// ParseDate accepts RFC3339 time, date with minutes or just a date
+func ParseDate(value string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't parse date %q, expected one of formats: %v", value, strings.Join(dateLayouts, ", "))
+}
+
+type WorkDates struct {
+	Start  string `json:"start"`
+	Finish string `json:"finish"`
+}
+
+// Dates are real dates of a schedule, whole day units are formatted as dates
+// and finish is the last working day, otherwise finish is the end of the last unit
+type Dates struct {
+	Start  string               `json:"start"`
+	Finish string               `json:"finish"`
+	Works  map[WorkID]WorkDates `json:"works"`
+}
+
+type calendar struct {
+	start       time.Time
+	workingDays map[time.Weekday]struct{}
+	holidays    map[string]struct{}
+	unit        time.Duration
+	perDay      uint
+	wholeDays   bool
+	// working days found so far, days[i] is the beginning of the i-th working day
+	days []time.Time
+}
+
+func (task *Task) calendar() (*calendar, error) {
+	start, err := ParseDate(task.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	c := &calendar{
+		start:       start,
+		workingDays: make(map[time.Weekday]struct{}),
+		holidays:    make(map[string]struct{}),
+		unit:        24 * time.Hour,
+		perDay:      1,
+		wholeDays:   true,
+	}
+	settings := Calendar{}
+	if task.Calendar != nil {
+		settings = *task.Calendar
+	}
+
+	workingDays := settings.WorkingDays
+	if len(workingDays) == 0 {
+		workingDays = defaultWorkingDays
+	}
+	for _, name := range workingDays {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", name)
+		}
+		c.workingDays[day] = struct{}{}
+	}
+	for _, holiday := range settings.Holidays {
+		date, err := time.Parse(dayLayout, holiday)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse holiday %q, expected format %v", holiday, dayLayout)
+		}
+		c.holidays[date.Format(dayLayout)] = struct{}{}
+	}
+	if settings.Unit != "" {
+		c.unit, err = time.ParseDuration(settings.Unit)
+		if err != nil || c.unit <= 0 {
+			return nil, fmt.Errorf("invalid time unit %q", settings.Unit)
+		}
+		c.wholeDays = false
+	}
+	if settings.UnitsPerDay != 0 {
+		c.perDay = settings.UnitsPerDay
+	}
+	if c.unit*time.Duration(c.perDay) > 24*time.Hour {
+		return nil, fmt.Errorf("%v units of %v don't fit in a day", c.perDay, c.unit)
+	}
+	return c, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func (c *calendar) isWorking(day time.Time) bool {
+	if _, ok := c.workingDays[day.Weekday()]; !ok {
+		return false
+	}
+	_, holiday := c.holidays[day.Format(dayLayout)]
+	return !holiday
+}
+
+// workingDay returns the beginning of the n-th working day from the start
+func (c *calendar) workingDay(n uint) time.Time {
+	for uint(len(c.days)) <= n {
+		day := c.start
+		if len(c.days) > 0 {
+			day = c.days[len(c.days)-1].AddDate(0, 0, 1)
+		}
+		for !c.isWorking(day) {
+			day = day.AddDate(0, 0, 1)
+		}
+		c.days = append(c.days, day)
+	}
+	return c.days[n]
+}
+
+// at returns the beginning of the time unit
+func (c *calendar) at(unit uint) time.Time {
+	return c.workingDay(unit / c.perDay).Add(time.Duration(unit%c.perDay) * c.unit)
+}
+
+func (c *calendar) format(start uint, finish uint) WorkDates {
+	layout := time.RFC3339
+	if c.wholeDays {
+		layout = dayLayout
+	}
+	from, to := c.at(start), c.at(start)
+	if finish > start {
+		to = c.at(finish - 1)
+		if !c.wholeDays {
+			to = to.Add(c.unit)
+		}
+	}
+	return WorkDates{Start: from.Format(layout), Finish: to.Format(layout)}
+}
+
+// ScheduleDates converts time units of the schedule to dates of the task calendar,
+// it returns nil when the task has no start date
+func (task *Task) ScheduleDates(schedule Schedule) (*Dates, error) {
+	if task.StartDate == "" {
+		return nil, nil
+	}
+	c, err := task.calendar()
+	if err != nil {
+		return nil, err
+	}
+	total := c.format(0, schedule.Time)
+	dates := &Dates{
+		Start:  total.Start,
+		Finish: total.Finish,
+		Works:  make(map[WorkID]WorkDates, len(schedule.Works)),
+	}
+	for id, work := range schedule.Works {
+		dates.Works[id] = c.format(work.Start, work.Finish)
+	}
+	return dates, nil
+}
@@ -1,147 +1,450 @@
 package core
 
 import (
-	"math"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
 )
 
+// resource pool of tasks stored without explicit ones
+const DefaultResource ResourceID = "workers"
+const DefaultCapacity = 10
+
 type WorkID string
+type ResourceID string
 
 type Task struct {
-	Name      string          `json:"task_name" bson:"_id"`
-	StartDate string          `json:"start_date" bson:"start_date"`
-	Works     map[WorkID]Work `json:"works" bson:"works"`
+	Name      string              `json:"task_name" bson:"_id"`
+	StartDate string              `json:"start_date" bson:"start_date"`
+	Calendar  *Calendar           `json:"calendar" bson:"calendar"`
+	Resources map[ResourceID]uint `json:"resources" bson:"resources"`
+	Works     map[WorkID]Work     `json:"works" bson:"works"`
 }
 
 type Work struct {
-	Name              WorkID              `json:"work_name" bson:"work_name"`
-	Duration          uint                `json:"duration" bson:"duration"`
-	ResourceNeeds     uint                `json:"resources" bson:"resource_needs"`
-	WorksNeedToBeDone map[WorkID]struct{} `json:"works_need_to_be_done" bson:"works_need_to_be_done"`
+	Name              WorkID                `json:"work_name" bson:"work_name"`
+	Duration          uint                  `json:"duration" bson:"duration"`
+	ResourceNeeds     map[ResourceID]uint   `json:"resources" bson:"resource_needs"`
+	WorksNeedToBeDone map[WorkID]Dependency `json:"works_need_to_be_done" bson:"works_need_to_be_done"`
+	// when modes are set Duration and ResourceNeeds are ignored
+	Modes []Mode `json:"modes,omitempty" bson:"modes,omitempty"`
+	// the work never starts before earliest start and should finish by deadline
+	EarliestStart *uint `json:"earliest_start,omitempty" bson:"earliest_start,omitempty"`
+	Deadline      *uint `json:"deadline,omitempty" bson:"deadline,omitempty"`
+	// uncertain duration used by simulation instead of Duration
+	Estimate *Estimate `json:"estimate,omitempty" bson:"estimate,omitempty"`
 }
 
-func isAvailable(done map[WorkID]struct{}, work Work) bool {
-	for workID := range work.WorksNeedToBeDone {
-		if _, ok := done[workID]; !ok {
-			return false
-		}
-	}
-	return true
+type ScheduledWork struct {
+	Start  uint `json:"start"`
+	Finish uint `json:"finish"`
+	// name of the chosen mode, empty for works without modes
+	Mode string `json:"mode,omitempty"`
+}
+
+type Schedule struct {
+	Time  uint                     `json:"time"`
+	Works map[WorkID]ScheduledWork `json:"works"`
+	// how late works finish after their deadlines, in total and per work
+	Lateness uint            `json:"lateness"`
+	Late     map[WorkID]uint `json:"late,omitempty"`
+	profile  *profile
 }
 
-func createSequence(works map[WorkID]Work) []WorkID {
-	sequence := make([]WorkID, len(works), len(works))
-	notAvailable := make(map[WorkID]struct{})
-	available := make(map[int]WorkID)
-	done := make(map[WorkID]struct{})
+// UsageSegment is usage of a resource pool from Start up to Finish, not including Finish
+type UsageSegment struct {
+	Start  uint `json:"start"`
+	Finish uint `json:"finish"`
+	Used   uint `json:"used"`
+}
 
-	for id, work := range works {
-		if len(work.WorksNeedToBeDone) == 0 {
-			available[len(available)] = id
-		} else {
-			notAvailable[id] = struct{}{}
-		}
+// Resources returns usage of every resource pool as segments where it doesn't change,
+// they cover time from 0 till the end of the schedule
+func (s Schedule) Resources() map[ResourceID][]UsageSegment {
+	if s.profile == nil {
+		return nil
 	}
-	i := 0
-	counter := len(works)
-	for len(available) > 0 && counter > 0 {
+	return s.profile.usage()
+}
 
-		randI := rand.Intn(len(available))
-		available[randI], available[len(available)-1] = available[len(available)-1], available[randI]
-		workID := available[len(available)-1]
+// maxTime limits starts, durations and lags, so times of schedules summed from them fit in int
+const maxTime = 1 << 40
 
-		delete(available, len(available)-1)
+// problem keeps task data prepared for scheduling: resource pools are numbered
+// and needs of every work mode are stored as a slice indexed by pool number
+type problem struct {
+	task     *Task
+	ids      []WorkID
+	pools    []ResourceID
+	capacity []uint
+	modes    map[WorkID][]mode
+	// topology of works by their positions in ids: sorted successors
+	// and number of predecessors of every work, works without predecessors
+	succs    [][]int
+	indegree []int
+	roots    []int
+	// no work can start before its predecessors, so serial schedules include an optimal one
+	ordered bool
+}
 
-		done[workID] = struct{}{}
+func newProblem(task *Task) (*problem, error) {
+	if cycle := task.FindCycle(); cycle != nil {
+		return nil, &CycleError{Cycle: cycle}
+	}
+	pr := &problem{
+		task:     task,
+		pools:    make([]ResourceID, 0, len(task.Resources)),
+		capacity: make([]uint, 0, len(task.Resources)),
+		modes:    make(map[WorkID][]mode, len(task.Works)),
+		ids:      make([]WorkID, 0, len(task.Works)),
+		ordered:  true,
+	}
+	for id := range task.Works {
+		pr.ids = append(pr.ids, id)
+	}
+	sort.Slice(pr.ids, func(i, j int) bool { return pr.ids[i] < pr.ids[j] })
+	position := make(map[WorkID]int, len(pr.ids))
+	for i, id := range pr.ids {
+		position[id] = i
+	}
+	for pool := range task.Resources {
+		pr.pools = append(pr.pools, pool)
+	}
+	sort.Slice(pr.pools, func(i, j int) bool { return pr.pools[i] < pr.pools[j] })
+	index := make(map[ResourceID]int, len(pr.pools))
+	for i, pool := range pr.pools {
+		if task.Resources[pool] == 0 {
+			return nil, fmt.Errorf("resource %v has zero capacity", pool)
+		}
+		index[pool] = i
+		pr.capacity = append(pr.capacity, task.Resources[pool])
+	}
 
-		for id, _ := range notAvailable {
-			if isAvailable(done, works[id]) {
-				available[len(available)] = id
-				delete(notAvailable, id)
+	for workID, work := range task.Works {
+		for pred, dep := range work.WorksNeedToBeDone {
+			if _, ok := task.Works[pred]; !ok {
+				return nil, fmt.Errorf("work %v needs unknown work %v", workID, pred)
+			}
+			if err := dep.check(); err != nil {
+				return nil, fmt.Errorf("work %v has invalid dependency on %v: %v", workID, pred, err)
+			}
+			if dep.Lag > maxTime || dep.Lag < -maxTime {
+				return nil, fmt.Errorf("work %v has lag %v on %v beyond max time %v", workID, dep.Lag, pred, maxTime)
+			}
+			for _, predMode := range task.Works[pred].modes() {
+				for _, workMode := range work.modes() {
+					if dep.shift(predMode.Duration, workMode.Duration)+int(predMode.Duration) < 0 {
+						pr.ordered = false
+					}
+				}
 			}
 		}
-		sequence[i] = workID
-		i += 1
+		if work.EarliestStart != nil && *work.EarliestStart > maxTime || work.Deadline != nil && *work.Deadline > maxTime {
+			return nil, fmt.Errorf("work %v has earliest start or deadline after max time %v", workID, uint(maxTime))
+		}
+		for _, workMode := range work.modes() {
+			if workMode.Duration > maxTime {
+				return nil, fmt.Errorf("work %v has duration %v longer than max time %v", workID, workMode.Duration, uint(maxTime))
+			}
+			needs := make([]uint, len(pr.pools))
+			for pool, need := range workMode.ResourceNeeds {
+				i, ok := index[pool]
+				if !ok {
+					return nil, fmt.Errorf("work %v needs unknown resource %v", workID, pool)
+				}
+				if need > pr.capacity[i] {
+					return nil, fmt.Errorf("work %v needs %v of %v, more than capacity %v", workID, need, pool, pr.capacity[i])
+				}
+				needs[i] = need
+			}
+			pr.modes[workID] = append(pr.modes[workID], mode{workMode.Name, workMode.Duration, needs})
+		}
+	}
+	pr.succs = make([][]int, len(pr.ids))
+	pr.indegree = make([]int, len(pr.ids))
+	for i, id := range pr.ids {
+		for pred := range task.Works[id].WorksNeedToBeDone {
+			pr.succs[position[pred]] = append(pr.succs[position[pred]], i)
+		}
+		pr.indegree[i] = len(task.Works[id].WorksNeedToBeDone)
+		if pr.indegree[i] == 0 {
+			pr.roots = append(pr.roots, i)
+		}
+	}
+	return pr, nil
+}
+
+// Check returns error when the task can't be scheduled: dependency cycle,
+// unknown works or resources, or needs more than capacity
+func (task *Task) Check() error {
+	_, err := newProblem(task)
+	return err
+}
+
+// Fingerprint is a hash of everything calculation depends on: works with their
+// durations, modes, needs and dependencies, resource capacity and calendar,
+// tasks which differ only by name have the same fingerprint
+func (task *Task) Fingerprint() (string, error) {
+	content := *task
+	content.Name = ""
+	// maps are encoded with sorted keys, so the encoding is canonical
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return "", err
 	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
 
-	return sequence
+func (pr *problem) newSchedule(placed map[WorkID]ScheduledWork, resources *profile) Schedule {
+	schedule := Schedule{
+		Time:    uint(resources.length),
+		Works:   make(map[WorkID]ScheduledWork, len(placed)),
+		profile: resources.copy(),
+	}
+	for workID, work := range placed {
+		schedule.Works[workID] = work
+	}
+	pr.checkDeadlines(&schedule)
+	return schedule
 }
 
-func canEmplaceWork(resources []uint, work Work, index int) bool {
-	for i := index; i < len(resources) && i < index+int(work.Duration); i++ {
-		if resources[i]+work.ResourceNeeds > 10 {
+func isAvailable(done map[WorkID]struct{}, work Work) bool {
+	for workID := range work.WorksNeedToBeDone {
+		if _, ok := done[workID]; !ok {
 			return false
 		}
 	}
 	return true
+}
+
+// createSequence returns random order of works where every work goes after its predecessors,
+// a work becomes available when the counter of its not done predecessors drops to zero,
+// successors are sorted so the same rng gives the same sequence
+func (pr *problem) createSequence(rng *rand.Rand) []WorkID {
+	sequence := make([]WorkID, 0, len(pr.ids))
+	waiting := append(make([]int, 0, len(pr.ids)), pr.indegree...)
+	available := append(make([]int, 0, len(pr.ids)), pr.roots...)
+
+	for len(available) > 0 {
+		randI := rng.Intn(len(available))
+		available[randI], available[len(available)-1] = available[len(available)-1], available[randI]
+		work := available[len(available)-1]
+		available = available[:len(available)-1]
 
+		for _, succ := range pr.succs[work] {
+			if waiting[succ]--; waiting[succ] == 0 {
+				available = append(available, succ)
+			}
+		}
+		sequence = append(sequence, pr.ids[work])
+	}
+	return sequence
 }
-func emplaceWork(resources []uint, work Work, start int) []uint {
-	if len(resources) < start+int(work.Duration) {
-		need := start + int(work.Duration) - len(resources)
-		buff := make([]uint, need, need)
-		resources = append(resources, buff...)
+
+// findStart returns the earliest start of the work in the mode
+// after all its already placed predecessors
+func (pr *problem) findStart(resources *profile, placed map[WorkID]ScheduledWork, workID WorkID, m mode) int {
+	i := 0
+	if release := pr.task.Works[workID].EarliestStart; release != nil {
+		i = int(*release)
 	}
-	for i := start; i < start+int(work.Duration); i++ {
-		resources[i] += work.ResourceNeeds
+	for workId, dep := range pr.task.Works[workID].WorksNeedToBeDone {
+		pred := placed[workId]
+		if start := int(pred.Finish) + dep.shift(pred.Finish-pred.Start, m.duration); i < start {
+			i = start
+		}
 	}
-	return resources
+	return resources.earliest(pr.capacity, m.needs, m.duration, i)
 }
 
-func (task *Task) calculateMinimalTime() uint {
+// place returns the mode of the work which finishes first and its start
+func (pr *problem) place(resources *profile, placed map[WorkID]ScheduledWork, workID WorkID) (start int, chosen int) {
+	for i, m := range pr.modes[workID] {
+		if s := pr.findStart(resources, placed, workID, m); i == 0 ||
+			s+int(m.duration) < start+int(pr.modes[workID][chosen].duration) {
+			start, chosen = s, i
+		}
+	}
+	return start, chosen
+}
 
-	sequence := createSequence(task.Works)
-	resources := make([]uint, 0, 0)
-	finishedData := make(map[WorkID]int)
+// scheduleSequence places works one by one in the given order at the earliest
+// possible time, sequence must list every work after all its predecessors
+func (pr *problem) scheduleSequence(sequence []WorkID) Schedule {
+	resources := newProfile(pr.pools)
+	scheduled := make(map[WorkID]ScheduledWork, len(pr.task.Works))
 	for _, workID := range sequence {
-		i := 0
-		work := task.Works[workID]
-		for workId, _ := range work.WorksNeedToBeDone {
-			if i < finishedData[workId] {
-				i = finishedData[workId]
-			}
+		i, chosen := pr.place(resources, scheduled, workID)
+		m := pr.modes[workID][chosen]
+		resources.emplace(m.needs, m.duration, i)
+		scheduled[workID] = ScheduledWork{Start: uint(i), Finish: uint(i) + m.duration, Mode: m.name}
+	}
+	schedule := Schedule{
+		Time:    uint(resources.length),
+		Works:   scheduled,
+		profile: resources,
+	}
+	pr.checkDeadlines(&schedule)
+	return schedule
+}
+
+// lowerBound returns makespan no schedule can be shorter than: the longest chain
+// of works from their earliest start or the time the strictest pool needs
+// to do all works at full capacity
+func (pr *problem) lowerBound() uint {
+	var bound uint
+	for id, tails := range calculateTails(pr.task.Works) {
+		tail := minTail(tails)
+		if release := pr.task.Works[id].EarliestStart; release != nil {
+			tail += *release
 		}
-		for ; i <= len(resources); i++ {
-			if canEmplaceWork(resources, work, i) {
-				break
-			}
+		if tail > bound {
+			bound = tail
+		}
+	}
+	for pool, capacity := range pr.capacity {
+		var energy uint
+		for id := range pr.task.Works {
+			energy += pr.minEnergy(id, pool)
+		}
+		if energy == 0 {
+			continue
+		}
+		if energy = (energy + capacity - 1) / capacity; energy > bound {
+			bound = energy
 		}
-		resources = emplaceWork(resources, work, i)
-		finishedData[workID] = i + int(work.Duration)
 	}
-	//fmt.Println(resources)
-	return uint(len(resources))
+	return bound
+}
 
+// minEnergy returns the smallest duration by need product of the work modes in the pool
+func (pr *problem) minEnergy(workID WorkID, pool int) uint {
+	var energy uint
+	for i, m := range pr.modes[workID] {
+		if i == 0 || m.duration*m.needs[pool] < energy {
+			energy = m.duration * m.needs[pool]
+		}
+	}
+	return energy
 }
 
-func (task *Task) StartCalculation() (ans uint64, err error) {
-	maxGorutines := 10
+func (pr *problem) calculateMinimalTime(rng *rand.Rand) Schedule {
+	return pr.scheduleSequence(pr.createSequence(rng))
+}
 
-	gather := make(chan uint, maxGorutines)
-	stopper := make(chan struct{}, maxGorutines)
-	result := make(chan float64)
-	numOfIterations := 1000 * 1000
+// StartCalculation schedules numOfIterations random sequences of works
+// and returns the shortest schedule. Iterations are split into chunks of samplingChunk,
+// every chunk has its own random source derived from seed, so the same seed always
+// gives the same result whatever the number of workers is. Every worker keeps its own
+// best schedule and they are merged once all workers are done.
+// Sampling stops as soon as a schedule meets the lower bound, such schedule is optimal.
+// When ctx is done the best of already scheduled sequences is returned as partial result.
+func (task *Task) StartCalculation(ctx context.Context, numOfIterations int, workers int, opts Options) (res Result, err error) {
+	pr, err := newProblem(task)
+	if err != nil {
+		return res, err
+	}
+	chunks := (numOfIterations + samplingChunk - 1) / samplingChunk
+	if workers > chunks {
+		workers = chunks
+	}
 
-	go func() {
-		var min float64
-		for i := 0; i < numOfIterations; i++ {
-			res := float64(<-gather)
-			if i != 0 {
-				min = math.Min(min, res)
-			} else {
-				min = res
+	bound := pr.lowerBound()
+	// iterations after the first one which met the bound are skipped,
+	// all iterations before it are still done, so the result doesn't depend on timing
+	stopAt := int64(numOfIterations)
+	var nextChunk int64
+	var cancelled int32
+	best := make([]sample, workers)
+	// improvements of workers are sent only to report progress
+	var improvements chan sample
+	if opts.Progress != nil {
+		improvements = make(chan sample, workers)
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(local *sample) {
+			defer wg.Done()
+			local.iteration = -1
+			for {
+				chunk := int(atomic.AddInt64(&nextChunk, 1) - 1)
+				i := chunk * samplingChunk
+				if int64(i) >= atomic.LoadInt64(&stopAt) {
+					return
+				}
+				rng := rand.New(rand.NewSource(DeriveSeed(opts.Seed, chunk)))
+				for end := i + samplingChunk; i < end && int64(i) < atomic.LoadInt64(&stopAt); i++ {
+					if ctx.Err() != nil {
+						atomic.StoreInt32(&cancelled, 1)
+						return
+					}
+					schedule := pr.calculateMinimalTime(rng)
+					if schedule.reaches(bound) {
+						for stop := atomic.LoadInt64(&stopAt); int64(i+1) < stop; stop = atomic.LoadInt64(&stopAt) {
+							if atomic.CompareAndSwapInt64(&stopAt, stop, int64(i+1)) {
+								break
+							}
+						}
+					}
+					if current := (sample{schedule, i}); local.iteration < 0 || current.better(*local) {
+						*local = current
+						if improvements != nil {
+							improvements <- *local
+						}
+					}
+				}
 			}
-		}
-		result <- min
-	}()
-
-	for i := 0; i < numOfIterations; i++ {
-		stopper <- struct{}{}
+		}(&best[w])
+	}
+	if improvements != nil {
 		go func() {
-			gather <- task.calculateMinimalTime()
-			<-stopper
+			wg.Wait()
+			close(improvements)
 		}()
+		report := newReporter(opts)
+		for improvement := range improvements {
+			report.improved(improvement.schedule, improvement.iteration+1)
+		}
 	}
+	wg.Wait()
 
-	return uint64(<-result), nil
+	min := sample{iteration: -1}
+	for _, local := range best {
+		if local.iteration >= 0 && (min.iteration < 0 || local.better(min)) {
+			min = local
+		}
+	}
+	if min.iteration < 0 && numOfIterations > 0 {
+		return res, ctx.Err()
+	}
+	res.Schedule = min.schedule
+	res.LowerBound = bound
+	res.Optimal = min.schedule.reaches(bound)
+	res.Partial = cancelled == 1 && !res.Optimal
+	return res, nil
+}
+
+// samplingChunk is number of iterations of StartCalculation with the same random source
+const samplingChunk = 1024
+
+type sample struct {
+	schedule  Schedule
+	iteration int
+}
+
+// better compares samples by their schedules and then by iteration,
+// so the merged result doesn't depend on which worker found it
+func (s sample) better(other sample) bool {
+	if s.schedule.better(other.schedule) {
+		return true
+	}
+	return !other.schedule.better(s.schedule) && s.iteration < other.iteration
 }
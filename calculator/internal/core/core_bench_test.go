@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+const benchIterations = 2000
+
+// benchTask returns task of random works, every work needs a few earlier ones
+func benchTask(works int) *Task {
+	rng := rand.New(rand.NewSource(1))
+	task := &Task{
+		Name:      "bench",
+		Resources: map[ResourceID]uint{"workers": 10, "cranes": 3},
+		Works:     make(map[WorkID]Work, works),
+	}
+	for i := 0; i < works; i++ {
+		id := WorkID(fmt.Sprintf("work%03d", i))
+		needs := make(map[WorkID]Dependency)
+		for j := 0; j < i; j++ {
+			if rng.Intn(works) < 3 {
+				needs[WorkID(fmt.Sprintf("work%03d", j))] = Dependency{}
+			}
+		}
+		task.Works[id] = Work{
+			Name:              id,
+			Duration:          uint(1 + rng.Intn(9)),
+			ResourceNeeds:     map[ResourceID]uint{"workers": uint(rng.Intn(11)), "cranes": uint(rng.Intn(4))},
+			WorksNeedToBeDone: needs,
+		}
+	}
+	return task
+}
+
+// goroutinePerIteration is the former sampling model kept to compare with:
+// a goroutine for every iteration and all schedules gathered by one reducer
+func goroutinePerIteration(task *Task, iterations int, goroutines int, seed int64) Schedule {
+	pr, err := newProblem(task)
+	if err != nil {
+		panic(err)
+	}
+	gather := make(chan Schedule, goroutines)
+	limit := make(chan struct{}, goroutines)
+	wg := sync.WaitGroup{}
+	wg.Add(iterations)
+	for i := 0; i < iterations; i++ {
+		go func(i int) {
+			defer wg.Done()
+			limit <- struct{}{}
+			gather <- pr.calculateMinimalTime(rand.New(rand.NewSource(DeriveSeed(seed, i))))
+			<-limit
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(gather)
+	}()
+	var min Schedule
+	received := 0
+	for schedule := range gather {
+		if received == 0 || schedule.better(min) {
+			min = schedule
+		}
+		received++
+	}
+	return min
+}
+
+func BenchmarkSampling(b *testing.B) {
+	for _, works := range []int{10, 50, 200} {
+		task := benchTask(works)
+		b.Run(fmt.Sprintf("works=%v/goroutine-per-iteration", works), func(b *testing.B) {
+			start := time.Now()
+			for n := 0; n < b.N; n++ {
+				goroutinePerIteration(task, benchIterations, runtime.GOMAXPROCS(0), int64(n))
+			}
+			reportThroughput(b, start)
+		})
+		for _, workers := range benchWorkers() {
+			b.Run(fmt.Sprintf("works=%v/workers=%v", works, workers), func(b *testing.B) {
+				start := time.Now()
+				for n := 0; n < b.N; n++ {
+					_, err := task.StartCalculation(context.Background(), benchIterations, workers, Options{Seed: int64(n)})
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				reportThroughput(b, start)
+			})
+		}
+	}
+}
+
+func benchWorkers() []int {
+	if procs := runtime.GOMAXPROCS(0); procs > 1 {
+		return []int{1, procs}
+	}
+	return []int{1}
+}
+
+func reportThroughput(b *testing.B, start time.Time) {
+	b.ReportMetric(float64(b.N*benchIterations)/time.Since(start).Seconds(), "iterations/s")
+}
+
+// BenchmarkScheduleSequence shows that time of scheduling doesn't grow with durations
+func BenchmarkScheduleSequence(b *testing.B) {
+	for _, scale := range []uint{1, 1000} {
+		task := benchTask(200)
+		for id, work := range task.Works {
+			work.Duration *= scale
+			task.Works[id] = work
+		}
+		pr, err := newProblem(task)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("durations=x%v", scale), func(b *testing.B) {
+			rng := rand.New(rand.NewSource(1))
+			for n := 0; n < b.N; n++ {
+				pr.calculateMinimalTime(rng)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+type WorkTiming struct {
+	EarliestStart  uint `json:"earliest_start"`
+	EarliestFinish uint `json:"earliest_finish"`
+	LatestStart    uint `json:"latest_start"`
+	LatestFinish   uint `json:"latest_finish"`
+	TotalFloat     uint `json:"total_float"`
+}
+
+type CriticalPath struct {
+	Time     uint                  `json:"time"`
+	Works    map[WorkID]WorkTiming `json:"works"`
+	Critical []WorkID              `json:"critical"`
+}
+
+func successorsOf(works map[WorkID]Work) map[WorkID][]WorkID {
+	successors := make(map[WorkID][]WorkID, len(works))
+	for id, work := range works {
+		for pred := range work.WorksNeedToBeDone {
+			successors[pred] = append(successors[pred], id)
+		}
+	}
+	return successors
+}
+
+// topologicalOrder returns works ordered so that every work goes after all its predecessors
+func topologicalOrder(works map[WorkID]Work) ([]WorkID, error) {
+	successors := successorsOf(works)
+	indegree := make(map[WorkID]int, len(works))
+	queue := make([]WorkID, 0, len(works))
+	for id, work := range works {
+		indegree[id] = len(work.WorksNeedToBeDone)
+		if indegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	sort.Slice(queue, func(i, j int) bool { return queue[i] < queue[j] })
+
+	order := make([]WorkID, 0, len(works))
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		order = append(order, id)
+		for _, succ := range successors[id] {
+			indegree[succ]--
+			if indegree[succ] == 0 {
+				queue = append(queue, succ)
+			}
+		}
+	}
+	if len(order) != len(works) {
+		task := Task{Works: works}
+		if cycle := task.FindCycle(); cycle != nil {
+			return nil, &CycleError{Cycle: cycle}
+		}
+		return nil, fmt.Errorf("works depend on unknown works")
+	}
+	return order, nil
+}
+
+// FindCriticalPath runs critical path method over task works ignoring resource limits
+// and deadlines, works with several modes are taken in the shortest one
+func (task *Task) FindCriticalPath() (res CriticalPath, err error) {
+	order, err := topologicalOrder(task.Works)
+	if err != nil {
+		return res, err
+	}
+	works := shortestWorks(task.Works)
+	successors := successorsOf(works)
+	res.Works = make(map[WorkID]WorkTiming, len(works))
+
+	for _, id := range order {
+		work := works[id]
+		earliestStart := 0
+		if work.EarliestStart != nil {
+			earliestStart = int(*work.EarliestStart)
+		}
+		for pred, dep := range work.WorksNeedToBeDone {
+			if start := int(res.Works[pred].EarliestFinish) + dep.shift(works[pred].Duration, work.Duration); start > earliestStart {
+				earliestStart = start
+			}
+		}
+		timing := WorkTiming{EarliestStart: uint(earliestStart)}
+		timing.EarliestFinish = timing.EarliestStart + work.Duration
+		if timing.EarliestFinish > res.Time {
+			res.Time = timing.EarliestFinish
+		}
+		res.Works[id] = timing
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		id := order[i]
+		timing := res.Works[id]
+		latestFinish := int(res.Time)
+		for _, succ := range successors[id] {
+			shift := works[succ].WorksNeedToBeDone[id].shift(works[id].Duration, works[succ].Duration)
+			if finish := int(res.Works[succ].LatestStart) - shift; finish < latestFinish {
+				latestFinish = finish
+			}
+		}
+		timing.LatestFinish = uint(latestFinish)
+		timing.LatestStart = timing.LatestFinish - works[id].Duration
+		timing.TotalFloat = timing.LatestStart - timing.EarliestStart
+		res.Works[id] = timing
+	}
+
+	res.Critical = make([]WorkID, 0)
+	for _, id := range order {
+		if res.Works[id].TotalFloat == 0 {
+			res.Critical = append(res.Critical, id)
+		}
+	}
+	sort.SliceStable(res.Critical, func(i, j int) bool {
+		return res.Works[res.Critical[i]].EarliestStart < res.Works[res.Critical[j]].EarliestStart
+	})
+	return res, nil
+}
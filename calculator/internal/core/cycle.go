@@ -0,0 +1,76 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type CycleError struct {
+	Cycle []WorkID
+}
+
+func (e *CycleError) Error() string {
+	path := make([]string, len(e.Cycle))
+	for i, id := range e.Cycle {
+		path[i] = string(id)
+	}
+	return fmt.Sprintf("dependency cycle: %v", strings.Join(path, " -> "))
+}
+
+// FindCycle returns works forming a dependency cycle, every work in the result
+// needs the next one and the last one is equal to the first,
+// or nil when there are no cycles
+func (task *Task) FindCycle() []WorkID {
+	const (
+		unvisited = iota
+		inProgress
+		visited
+	)
+	state := make(map[WorkID]int, len(task.Works))
+	stack := make([]WorkID, 0)
+
+	var visit func(id WorkID) []WorkID
+	visit = func(id WorkID) []WorkID {
+		state[id] = inProgress
+		stack = append(stack, id)
+		needs := make([]WorkID, 0, len(task.Works[id].WorksNeedToBeDone))
+		for pred := range task.Works[id].WorksNeedToBeDone {
+			needs = append(needs, pred)
+		}
+		sort.Slice(needs, func(i, j int) bool { return needs[i] < needs[j] })
+
+		for _, pred := range needs {
+			switch state[pred] {
+			case inProgress:
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == pred {
+						cycle := append([]WorkID{}, stack[i:]...)
+						return append(cycle, pred)
+					}
+				}
+			case unvisited:
+				if cycle := visit(pred); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = visited
+		return nil
+	}
+
+	ids := make([]WorkID, 0, len(task.Works))
+	for id := range task.Works {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package core
+
+import "sort"
+
+type DeadlineViolation struct {
+	Work     WorkID `json:"work"`
+	Deadline uint   `json:"deadline"`
+	Finish   uint   `json:"finish"`
+	Lateness uint   `json:"lateness"`
+	// the deadline can't be met even without resource limits
+	Unavoidable bool `json:"unavoidable"`
+}
+
+// better compares schedules by total lateness and then by makespan
+func (s Schedule) better(other Schedule) bool {
+	if s.Lateness != other.Lateness {
+		return s.Lateness < other.Lateness
+	}
+	return s.Time < other.Time
+}
+
+// reaches reports whether the schedule meets all deadlines and the makespan bound,
+// so no other schedule is better
+func (s Schedule) reaches(bound uint) bool {
+	return s.Lateness == 0 && s.Time <= bound
+}
+
+// checkDeadlines counts how late every work finishes after its deadline
+func (pr *problem) checkDeadlines(schedule *Schedule) {
+	schedule.Lateness = 0
+	schedule.Late = nil
+	for id, work := range pr.task.Works {
+		if work.Deadline == nil || schedule.Works[id].Finish <= *work.Deadline {
+			continue
+		}
+		if schedule.Late == nil {
+			schedule.Late = make(map[WorkID]uint)
+		}
+		schedule.Late[id] = schedule.Works[id].Finish - *work.Deadline
+		schedule.Lateness += schedule.Late[id]
+	}
+}
+
+// DeadlineViolations lists works of the schedule which finish after their deadlines
+func (task *Task) DeadlineViolations(schedule Schedule) ([]DeadlineViolation, error) {
+	if len(schedule.Late) == 0 {
+		return nil, nil
+	}
+	path, err := task.FindCriticalPath()
+	if err != nil {
+		return nil, err
+	}
+	violations := make([]DeadlineViolation, 0, len(schedule.Late))
+	for id, lateness := range schedule.Late {
+		deadline := *task.Works[id].Deadline
+		violations = append(violations, DeadlineViolation{
+			Work:        id,
+			Deadline:    deadline,
+			Finish:      schedule.Works[id].Finish,
+			Lateness:    lateness,
+			Unavoidable: path.Works[id].EarliestFinish > deadline,
+		})
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Work < violations[j].Work })
+	return violations, nil
+}
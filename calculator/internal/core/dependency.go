@@ -0,0 +1,42 @@
+package core
+
+import "fmt"
+
+type DependencyType string
+
+const (
+	FinishToStart  DependencyType = "FS"
+	StartToStart   DependencyType = "SS"
+	FinishToFinish DependencyType = "FF"
+	StartToFinish  DependencyType = "SF"
+)
+
+// Dependency links a point (start or finish) of the needed work with a point
+// of the dependent work, the dependent point can't be earlier than Lag after
+// the needed one. Lag may be negative, empty type means finish-to-start.
+type Dependency struct {
+	Type DependencyType `json:"type,omitempty" bson:"type,omitempty"`
+	Lag  int            `json:"lag,omitempty" bson:"lag,omitempty"`
+}
+
+func (dep Dependency) check() error {
+	switch dep.Type {
+	case "", FinishToStart, StartToStart, FinishToFinish, StartToFinish:
+		return nil
+	}
+	return fmt.Errorf("unknown dependency type %q, expected one of FS, SS, FF, SF", dep.Type)
+}
+
+// shift returns how long after the finish of pred the work can start at the earliest,
+// so every dependency type is checked the same way as finish-to-start
+func (dep Dependency) shift(predDuration uint, duration uint) int {
+	switch dep.Type {
+	case StartToStart:
+		return dep.Lag - int(predDuration)
+	case FinishToFinish:
+		return dep.Lag - int(duration)
+	case StartToFinish:
+		return dep.Lag - int(predDuration) - int(duration)
+	}
+	return dep.Lag
+}
@@ -0,0 +1,276 @@
+package core
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+)
+
+// number of random schedules used as the first upper bound for branch-and-bound
+const initialSamples = 100
+
+// search checks whether it was cancelled once per this number of nodes
+const cancelCheckNodes = 1024
+
+type branchAndBound struct {
+	*problem
+	tails     map[WorkID][]uint
+	resources *profile
+	placed    map[WorkID]ScheduledWork
+	done      map[WorkID]struct{}
+	// the best schedule found so far and lateness of already placed works
+	schedule  Schedule
+	lateness  uint
+	bound     uint
+	nodes     int
+	budget    int
+	exhausted bool
+	ctx       context.Context
+	cancelled bool
+	report    *reporter
+}
+
+// tails counts for every work in every its mode the length of the longest precedence
+// chain which starts with this work, including its own duration and dependency lags,
+// the next works of the chain are taken in the modes giving the shortest chain
+func calculateTails(works map[WorkID]Work) map[WorkID][]uint {
+	successors := successorsOf(works)
+
+	tails := make(map[WorkID][]uint, len(works))
+	var visit func(id WorkID) []uint
+	visit = func(id WorkID) []uint {
+		if tail, ok := tails[id]; ok {
+			return tail
+		}
+		modes := works[id].modes()
+		tails[id] = make([]uint, len(modes))
+		for i, mode := range modes {
+			longest := 0
+			for _, succ := range successors[id] {
+				dep := works[succ].WorksNeedToBeDone[id]
+				shortest := 0
+				for j, succMode := range works[succ].modes() {
+					if tail := dep.shift(mode.Duration, succMode.Duration) + int(visit(succ)[j]); j == 0 || tail < shortest {
+						shortest = tail
+					}
+				}
+				if shortest > longest {
+					longest = shortest
+				}
+			}
+			tails[id][i] = mode.Duration + uint(longest)
+		}
+		return tails[id]
+	}
+	for id := range works {
+		visit(id)
+	}
+	return tails
+}
+
+func minTail(tails []uint) uint {
+	res := tails[0]
+	for _, tail := range tails[1:] {
+		if tail < res {
+			res = tail
+		}
+	}
+	return res
+}
+
+// energyBound returns the earliest time when the free capacity left by already
+// scheduled works is enough to fit the energy of all remaining works,
+// the strictest of all resource pools
+func (bb *branchAndBound) energyBound() uint {
+	var bound uint
+	for pool, capacity := range bb.capacity {
+		var remaining uint
+		for id := range bb.task.Works {
+			if _, ok := bb.done[id]; !ok {
+				remaining += bb.minEnergy(id, pool)
+			}
+		}
+		if remaining == 0 {
+			continue
+		}
+		var free uint
+		poolBound := uint(0)
+		for i, s := range bb.resources.segments {
+			used := s.usage[pool]
+			if used >= capacity {
+				continue
+			}
+			width := uint(bb.resources.end(i) - s.start)
+			if free+(capacity-used)*width >= remaining {
+				poolBound = uint(s.start) + (remaining-free+capacity-used-1)/(capacity-used)
+				free = remaining
+				break
+			}
+			free += (capacity - used) * width
+		}
+		if free < remaining {
+			poolBound = uint(bb.resources.length) + (remaining-free+capacity-1)/capacity
+		}
+		if poolBound > bound {
+			bound = poolBound
+		}
+	}
+	return bound
+}
+
+// precedenceBound returns the longest chain of remaining works, counted from
+// the finish of their already scheduled predecessors
+func (bb *branchAndBound) precedenceBound() uint {
+	var bound uint
+	for id, work := range bb.task.Works {
+		if _, ok := bb.done[id]; ok {
+			continue
+		}
+		var shortest uint
+		for i, m := range bb.modes[id] {
+			start := 0
+			if work.EarliestStart != nil {
+				start = int(*work.EarliestStart)
+			}
+			for pred, dep := range work.WorksNeedToBeDone {
+				if placed, ok := bb.placed[pred]; ok {
+					if s := int(placed.Finish) + dep.shift(placed.Finish-placed.Start, m.duration); s > start {
+						start = s
+					}
+				}
+			}
+			if chain := uint(start) + bb.tails[id][i]; i == 0 || chain < shortest {
+				shortest = chain
+			}
+		}
+		if shortest > bound {
+			bound = shortest
+		}
+	}
+	return bound
+}
+
+func (bb *branchAndBound) lowerBound() uint {
+	bound := uint(bb.resources.length)
+	if energy := bb.energyBound(); energy > bound {
+		bound = energy
+	}
+	if precedence := bb.precedenceBound(); precedence > bound {
+		bound = precedence
+	}
+	return bound
+}
+
+func (bb *branchAndBound) eligible() []WorkID {
+	eligible := make([]WorkID, 0)
+	for id, work := range bb.task.Works {
+		if _, ok := bb.done[id]; ok {
+			continue
+		}
+		if isAvailable(bb.done, work) {
+			eligible = append(eligible, id)
+		}
+	}
+	sort.Slice(eligible, func(i, j int) bool {
+		if tailI, tailJ := minTail(bb.tails[eligible[i]]), minTail(bb.tails[eligible[j]]); tailI != tailJ {
+			return tailI > tailJ
+		}
+		return eligible[i] < eligible[j]
+	})
+	return eligible
+}
+
+func (bb *branchAndBound) search() {
+	if len(bb.done) == len(bb.task.Works) {
+		if bb.lateness < bb.schedule.Lateness ||
+			(bb.lateness == bb.schedule.Lateness && uint(bb.resources.length) < bb.schedule.Time) {
+			bb.schedule = bb.newSchedule(bb.placed, bb.resources)
+			bb.report.improved(bb.schedule, bb.nodes)
+		}
+		return
+	}
+	if bb.nodes >= bb.budget {
+		bb.exhausted = true
+		return
+	}
+	if bb.nodes%cancelCheckNodes == 0 && bb.ctx.Err() != nil {
+		bb.exhausted = true
+		bb.cancelled = true
+		return
+	}
+	bb.nodes++
+	if bb.lateness > bb.schedule.Lateness ||
+		(bb.lateness == bb.schedule.Lateness && bb.lowerBound() >= bb.schedule.Time) {
+		return
+	}
+
+	for _, workID := range bb.eligible() {
+		for _, m := range bb.modes[workID] {
+			start := bb.findStart(bb.resources, bb.placed, workID, m)
+			prevLength := bb.resources.length
+
+			var late uint
+			if deadline := bb.task.Works[workID].Deadline; deadline != nil && uint(start)+m.duration > *deadline {
+				late = uint(start) + m.duration - *deadline
+			}
+
+			bb.resources.emplace(m.needs, m.duration, start)
+			bb.placed[workID] = ScheduledWork{Start: uint(start), Finish: uint(start) + m.duration, Mode: m.name}
+			bb.done[workID] = struct{}{}
+			bb.lateness += late
+
+			bb.search()
+
+			bb.lateness -= late
+			delete(bb.done, workID)
+			delete(bb.placed, workID)
+			bb.resources.remove(m.needs, m.duration, start, prevLength)
+			if bb.exhausted || bb.schedule.reaches(bb.bound) {
+				return
+			}
+		}
+	}
+}
+
+// FindOptimalTime runs branch-and-bound over all serial schedules of the task in all work modes.
+// budget limits the number of visited search nodes; Optimal in the result reports
+// whether the schedule is proven to be minimal or the budget was hit first.
+// When a work may start before its predecessor, the search can't prove optimality.
+// When ctx is done the best schedule found so far is returned as partial result.
+func (task *Task) FindOptimalTime(ctx context.Context, budget int, opts Options) (res Result, err error) {
+	pr, err := newProblem(task)
+	if err != nil {
+		return res, err
+	}
+	bb := &branchAndBound{
+		problem:   pr,
+		tails:     calculateTails(task.Works),
+		resources: newProfile(pr.pools),
+		placed:    make(map[WorkID]ScheduledWork),
+		done:      make(map[WorkID]struct{}),
+		budget:    budget,
+		ctx:       ctx,
+		report:    newReporter(opts),
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for i := 0; i < initialSamples; i++ {
+		if res := pr.calculateMinimalTime(rng); i == 0 || res.better(bb.schedule) {
+			bb.schedule = res
+			bb.report.improved(res, 0)
+		}
+	}
+	bb.bound = bb.lowerBound()
+	if len(task.Works) > 0 && !bb.schedule.reaches(bb.bound) {
+		bb.search()
+	}
+
+	res.Schedule = bb.schedule
+	res.Optimal = (!bb.exhausted && pr.ordered) || bb.schedule.reaches(bb.bound)
+	res.Partial = bb.cancelled && !res.Optimal
+	res.LowerBound = bb.bound
+	if res.Optimal && res.Schedule.Lateness == 0 {
+		// finished search proves that nothing is shorter than the found schedule
+		res.LowerBound = res.Schedule.Time
+	}
+	return res, nil
+}
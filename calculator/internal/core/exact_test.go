@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+// testTask returns task with one pool of the capacity, works are given as duration,
+// need of the pool and dependencies
+func testTask(capacity uint, works map[WorkID]testWork) *Task {
+	task := &Task{
+		Name:      "test",
+		Resources: map[ResourceID]uint{"workers": capacity},
+		Works:     make(map[WorkID]Work, len(works)),
+	}
+	for id, w := range works {
+		needs := w.needs
+		if needs == nil {
+			needs = map[WorkID]Dependency{}
+		}
+		task.Works[id] = Work{
+			Name:              id,
+			Duration:          w.duration,
+			ResourceNeeds:     map[ResourceID]uint{"workers": w.need},
+			WorksNeedToBeDone: needs,
+		}
+	}
+	return task
+}
+
+type testWork struct {
+	duration uint
+	need     uint
+	needs    map[WorkID]Dependency
+}
+
+func TestFindOptimalTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		task    *Task
+		time    uint
+		optimal bool
+		ordered bool
+	}{
+		{
+			// no two works fit together, the energy bound 4 is less than the optimum
+			name: "disjunctive",
+			task: testTask(3, map[WorkID]testWork{
+				"a": {duration: 1, need: 2},
+				"b": {duration: 2, need: 2},
+				"c": {duration: 3, need: 2},
+			}),
+			time:    6,
+			optimal: true,
+			ordered: true,
+		},
+		{
+			name: "chain and full pool",
+			task: testTask(2, map[WorkID]testWork{
+				"a": {duration: 3, need: 1},
+				"b": {duration: 4, need: 2},
+				"c": {duration: 2, need: 2, needs: map[WorkID]Dependency{"a": {}}},
+			}),
+			time:    9,
+			optimal: true,
+			ordered: true,
+		},
+		{
+			name: "parallel after predecessor",
+			task: testTask(3, map[WorkID]testWork{
+				"a": {duration: 1, need: 2},
+				"b": {duration: 1, need: 2},
+				"c": {duration: 1, need: 2},
+				"d": {duration: 2, need: 1, needs: map[WorkID]Dependency{"a": {}}},
+			}),
+			time:    3,
+			optimal: true,
+			ordered: true,
+		},
+		{
+			// the work may start before its predecessor, so the search can't prove optimality
+			name: "negative start to start lag",
+			task: testTask(3, map[WorkID]testWork{
+				"a": {duration: 1, need: 2},
+				"b": {duration: 2, need: 2, needs: map[WorkID]Dependency{"a": {Type: StartToStart, Lag: -1}}},
+				"c": {duration: 3, need: 2},
+			}),
+			time:    6,
+			optimal: false,
+			ordered: false,
+		},
+		{
+			name: "negative finish to start lag",
+			task: testTask(10, map[WorkID]testWork{
+				"a": {duration: 3, need: 1},
+				"b": {duration: 2, need: 1, needs: map[WorkID]Dependency{"a": {Lag: -4}}},
+			}),
+			time:    3,
+			optimal: true,
+			ordered: false,
+		},
+		{
+			name: "finish to finish",
+			task: testTask(10, map[WorkID]testWork{
+				"a": {duration: 1, need: 1},
+				"b": {duration: 3, need: 1, needs: map[WorkID]Dependency{"a": {Type: FinishToFinish}}},
+			}),
+			time:    3,
+			optimal: true,
+			ordered: false,
+		},
+		{
+			// the energy bound proves optimality even when works aren't ordered
+			name: "start to finish",
+			task: testTask(1, map[WorkID]testWork{
+				"a": {duration: 2, need: 1},
+				"b": {duration: 2, need: 1, needs: map[WorkID]Dependency{"a": {Type: StartToFinish}}},
+			}),
+			time:    4,
+			optimal: true,
+			ordered: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pr, err := newProblem(test.task)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pr.ordered != test.ordered {
+				t.Errorf("ordered is %v, expected %v", pr.ordered, test.ordered)
+			}
+			res, err := test.task.FindOptimalTime(context.Background(), 100000, Options{Seed: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Schedule.Time != test.time || res.Optimal != test.optimal {
+				t.Errorf("got time %v optimal %v, expected %v %v", res.Schedule.Time, res.Optimal, test.time, test.optimal)
+			}
+			if res.Optimal && res.LowerBound != res.Schedule.Time {
+				t.Errorf("optimal schedule of time %v has lower bound %v", res.Schedule.Time, res.LowerBound)
+			}
+			planned := make(map[WorkID]PlannedWork, len(res.Schedule.Works))
+			for id, work := range res.Schedule.Works {
+				planned[id] = PlannedWork{Start: work.Start, Mode: work.Mode}
+			}
+			if _, violations, err := test.task.ValidateSchedule(planned); err != nil || len(violations) != 0 {
+				t.Errorf("schedule %v is invalid: %v %v", res.Schedule.Works, violations, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import "sort"
+
+const (
+	// the work starts at time 0
+	StartReason = "start"
+	// the work waits for its earliest start
+	EarliestStartReason = "earliest_start"
+	// the work waits for its predecessor Cause
+	PrecedenceReason = "precedence"
+	// the work waits for Resource used by Cause after its predecessors allow it to start
+	ResourceReason = "resource"
+)
+
+// ChainLink is a work of the chain determining the makespan and the reason of its start time
+type ChainLink struct {
+	Work     WorkID     `json:"work"`
+	Start    uint       `json:"start"`
+	Finish   uint       `json:"finish"`
+	Reason   string     `json:"reason"`
+	Cause    WorkID     `json:"cause,omitempty"`
+	Resource ResourceID `json:"resource,omitempty"`
+	// how long the work waited for the resource after its predecessors allowed it to start
+	Wait uint `json:"wait,omitempty"`
+}
+
+// SaturatedPeriod is time from From up to To when all capacity of Resource is used
+type SaturatedPeriod struct {
+	Resource ResourceID `json:"resource"`
+	From     uint       `json:"from"`
+	To       uint       `json:"to"`
+}
+
+type Explanation struct {
+	// works from the first one to the one finishing last
+	Chain     []ChainLink       `json:"chain"`
+	Saturated []SaturatedPeriod `json:"saturated"`
+	// makespan without resource limits
+	UnconstrainedTime uint `json:"unconstrained_time"`
+}
+
+// Explain follows the schedule back from the work finishing last: every work
+// of the chain starts either when its predecessor allows or later because
+// a resource pool had no room for it, then the blocking work is the next one
+func (task *Task) Explain(schedule Schedule) (Explanation, error) {
+	var res Explanation
+	pr, err := newProblem(task)
+	if err != nil {
+		return res, err
+	}
+	path, err := task.FindCriticalPath()
+	if err != nil {
+		return res, err
+	}
+	res.UnconstrainedTime = path.Time
+
+	resources := newProfile(pr.pools)
+	chosen := make(map[WorkID]mode, len(pr.ids))
+	var last WorkID
+	for _, id := range pr.ids {
+		work, ok := schedule.Works[id]
+		if !ok {
+			continue
+		}
+		if chosen[id], err = pr.findMode(id, work.Mode); err != nil {
+			return res, err
+		}
+		resources.emplace(chosen[id].needs, chosen[id].duration, int(work.Start))
+		if last == "" || work.Finish > schedule.Works[last].Finish {
+			last = id
+		}
+	}
+	res.Saturated = pr.saturatedPeriods(resources)
+
+	visited := make(map[WorkID]bool)
+	for id := last; id != "" && !visited[id]; {
+		visited[id] = true
+		link := pr.explainStart(schedule, resources, chosen, id)
+		res.Chain = append(res.Chain, link)
+		id = link.Cause
+	}
+	for i, j := 0, len(res.Chain)-1; i < j; i, j = i+1, j-1 {
+		res.Chain[i], res.Chain[j] = res.Chain[j], res.Chain[i]
+	}
+	return res, nil
+}
+
+// explainStart finds why the work doesn't start earlier in the schedule
+func (pr *problem) explainStart(schedule Schedule, resources *profile, chosen map[WorkID]mode, id WorkID) ChainLink {
+	work := schedule.Works[id]
+	link := ChainLink{Work: id, Start: work.Start, Finish: work.Finish, Reason: StartReason}
+	ready := 0
+	if release := pr.task.Works[id].EarliestStart; release != nil && *release > 0 {
+		ready = int(*release)
+		link.Reason = EarliestStartReason
+	}
+	preds := make([]WorkID, 0, len(pr.task.Works[id].WorksNeedToBeDone))
+	for pred := range pr.task.Works[id].WorksNeedToBeDone {
+		preds = append(preds, pred)
+	}
+	sort.Slice(preds, func(i, j int) bool { return preds[i] < preds[j] })
+	for _, pred := range preds {
+		predWork := schedule.Works[pred]
+		if start := int(predWork.Finish) + pr.task.Works[id].WorksNeedToBeDone[pred].shift(chosen[pred].duration, chosen[id].duration); start > ready {
+			ready = start
+			link.Reason = PrecedenceReason
+			link.Cause = pred
+		}
+	}
+	if int(work.Start) <= ready {
+		return link
+	}
+
+	// the work doesn't fit one unit earlier, the pool overloaded then and
+	// the work using it which started before and finished last is the cause
+	link.Reason = ResourceReason
+	link.Cause = ""
+	link.Wait = work.Start - uint(ready)
+	m := chosen[id]
+	resources.remove(m.needs, m.duration, int(work.Start), resources.length)
+	defer resources.emplace(m.needs, m.duration, int(work.Start))
+	from, to := int(work.Start)-1, int(work.Start)-1+int(m.duration)
+	if m.duration == 0 {
+		to = from + 1
+	}
+	for i := resources.find(from); i >= 0 && i < len(resources.segments) && resources.segments[i].start < to; i++ {
+		for pool, need := range m.needs {
+			if need == 0 || resources.segments[i].usage[pool]+need <= pr.capacity[pool] {
+				continue
+			}
+			segmentFrom, segmentTo := resources.segments[i].start, resources.end(i)
+			for _, other := range pr.ids {
+				otherWork, ok := schedule.Works[other]
+				if !ok || other == id || chosen[other].needs[pool] == 0 || otherWork.Start >= work.Start ||
+					int(otherWork.Start) >= segmentTo || int(otherWork.Finish) <= segmentFrom {
+					continue
+				}
+				if link.Cause == "" || otherWork.Finish > schedule.Works[link.Cause].Finish {
+					link.Cause = other
+					link.Resource = pr.pools[pool]
+				}
+			}
+			if link.Cause != "" {
+				return link
+			}
+		}
+	}
+	return link
+}
+
+// saturatedPeriods joins adjacent segments where all capacity of a pool is used
+func (pr *problem) saturatedPeriods(resources *profile) []SaturatedPeriod {
+	var periods []SaturatedPeriod
+	for pool, capacity := range pr.capacity {
+		saturated := false
+		for i, s := range resources.segments {
+			if s.usage[pool] < capacity {
+				saturated = false
+				continue
+			}
+			if !saturated {
+				periods = append(periods, SaturatedPeriod{Resource: pr.pools[pool], From: uint(s.start)})
+				saturated = true
+			}
+			periods[len(periods)-1].To = uint(resources.end(i))
+		}
+	}
+	sort.SliceStable(periods, func(i, j int) bool { return periods[i].From < periods[j].From })
+	return periods
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+)
+
+type individual struct {
+	sequence []WorkID
+	schedule Schedule
+}
+
+// crossover takes first point works from mother and the rest in the order of father,
+// so the child keeps every work after its predecessors
+func crossover(mother []WorkID, father []WorkID, point int) []WorkID {
+	child := make([]WorkID, 0, len(mother))
+	taken := make(map[WorkID]struct{}, len(mother))
+	for _, id := range mother[:point] {
+		child = append(child, id)
+		taken[id] = struct{}{}
+	}
+	for _, id := range father {
+		if _, ok := taken[id]; !ok {
+			child = append(child, id)
+		}
+	}
+	return child
+}
+
+// mutate swaps neighbour works which don't depend on each other
+func mutate(rng *rand.Rand, works map[WorkID]Work, sequence []WorkID, rate float64) {
+	for i := 0; i+1 < len(sequence); i++ {
+		if rng.Float64() >= rate {
+			continue
+		}
+		if _, ok := works[sequence[i+1]].WorksNeedToBeDone[sequence[i]]; ok {
+			continue
+		}
+		sequence[i], sequence[i+1] = sequence[i+1], sequence[i]
+	}
+}
+
+func tournament(rng *rand.Rand, population []individual) individual {
+	first := population[rng.Intn(len(population))]
+	second := population[rng.Intn(len(population))]
+	if second.schedule.better(first.schedule) {
+		return second
+	}
+	return first
+}
+
+// geneticScheduler evolves population of work sequences,
+// parameters: population, generations, mutation
+type geneticScheduler struct{}
+
+func (geneticScheduler) Schedule(ctx context.Context, task *Task, opts Options) (res Result, err error) {
+	size, err := opts.Parameters.Int("population", 50)
+	if err != nil {
+		return res, err
+	}
+	generations, err := opts.Parameters.Int("generations", 200)
+	if err != nil {
+		return res, err
+	}
+	rate, err := opts.Parameters.Float("mutation", 0.05)
+	if err != nil {
+		return res, err
+	}
+	pr, err := newProblem(task)
+	if err != nil {
+		return res, err
+	}
+
+	res.LowerBound = pr.lowerBound()
+	rng := rand.New(rand.NewSource(opts.Seed))
+	report := newReporter(opts)
+	population := make([]individual, 0, 2*size)
+	for len(population) < size {
+		sequence := pr.createSequence(rng)
+		population = append(population, individual{sequence, pr.scheduleSequence(sequence)})
+		report.improved(population[len(population)-1].schedule, len(population))
+	}
+	evaluated := len(population)
+	best := population[0].schedule
+	for _, individual := range population {
+		if individual.schedule.better(best) {
+			best = individual.schedule
+		}
+	}
+
+	for generation := 0; generation < generations && !best.reaches(res.LowerBound); generation++ {
+		if ctx.Err() != nil {
+			res.Partial = true
+			break
+		}
+		for i := 0; i < size; i += 2 {
+			mother, father := tournament(rng, population[:size]), tournament(rng, population[:size])
+			point := rng.Intn(len(mother.sequence) + 1)
+			for _, child := range [][]WorkID{
+				crossover(mother.sequence, father.sequence, point),
+				crossover(father.sequence, mother.sequence, point),
+			} {
+				mutate(rng, task.Works, child, rate)
+				population = append(population, individual{child, pr.scheduleSequence(child)})
+				evaluated++
+				report.improved(population[len(population)-1].schedule, evaluated)
+				if population[len(population)-1].schedule.better(best) {
+					best = population[len(population)-1].schedule
+				}
+			}
+		}
+		sort.SliceStable(population, func(i, j int) bool {
+			return population[i].schedule.better(population[j].schedule)
+		})
+		population = population[:size]
+	}
+
+	sort.SliceStable(population, func(i, j int) bool {
+		return population[i].schedule.better(population[j].schedule)
+	})
+	res.Schedule = population[0].schedule
+	res.Optimal = res.Schedule.reaches(res.LowerBound)
+	return res, nil
+}
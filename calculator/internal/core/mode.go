@@ -0,0 +1,49 @@
+package core
+
+// Mode is one of the ways to do a work, works without modes are done
+// in the only mode made of their Duration and ResourceNeeds
+type Mode struct {
+	Name          string              `json:"name" bson:"name"`
+	Duration      uint                `json:"duration" bson:"duration"`
+	ResourceNeeds map[ResourceID]uint `json:"resources" bson:"resource_needs"`
+}
+
+func (work Work) modes() []Mode {
+	if len(work.Modes) == 0 {
+		return []Mode{{Duration: work.Duration, ResourceNeeds: work.ResourceNeeds}}
+	}
+	return work.Modes
+}
+
+// shortest returns work in its mode with the shortest duration,
+// it's used where the mode isn't chosen yet, like critical path method
+func (work Work) shortest() Work {
+	if len(work.Modes) == 0 {
+		return work
+	}
+	best := work.Modes[0]
+	for _, mode := range work.Modes[1:] {
+		if mode.Duration < best.Duration {
+			best = mode
+		}
+	}
+	work.Duration = best.Duration
+	work.ResourceNeeds = best.ResourceNeeds
+	work.Modes = nil
+	return work
+}
+
+func shortestWorks(works map[WorkID]Work) map[WorkID]Work {
+	res := make(map[WorkID]Work, len(works))
+	for id, work := range works {
+		res[id] = work.shortest()
+	}
+	return res
+}
+
+// mode is Mode prepared for scheduling
+type mode struct {
+	name     string
+	duration uint
+	needs    []uint
+}
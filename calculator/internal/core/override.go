@@ -0,0 +1,97 @@
+package core
+
+import "fmt"
+
+// Override is a change of a task for what-if calculation. Changes of the work are
+// applied when Work is set, otherwise only Capacity of the task pools is changed.
+type Override struct {
+	Work WorkID
+	// removes the work and all dependencies on it
+	Remove        bool
+	Duration      *uint
+	ResourceNeeds map[ResourceID]uint
+	// dependencies of the work on other works
+	RemoveDependencies []WorkID
+	AddDependencies    map[WorkID]Dependency
+	Capacity           map[ResourceID]uint
+}
+
+// clone copies task deep enough to change its works and resources
+func (task Task) clone() Task {
+	res := task
+	res.Resources = make(map[ResourceID]uint, len(task.Resources))
+	for pool, capacity := range task.Resources {
+		res.Resources[pool] = capacity
+	}
+	res.Works = make(map[WorkID]Work, len(task.Works))
+	for id, work := range task.Works {
+		needs := make(map[WorkID]Dependency, len(work.WorksNeedToBeDone))
+		for pred, dep := range work.WorksNeedToBeDone {
+			needs[pred] = dep
+		}
+		work.WorksNeedToBeDone = needs
+		res.Works[id] = work
+	}
+	return res
+}
+
+// WithOverrides returns copy of the task with all overrides applied in order,
+// the task itself isn't changed
+func (task Task) WithOverrides(overrides []Override) (Task, error) {
+	res := task.clone()
+	for _, override := range overrides {
+		for pool, capacity := range override.Capacity {
+			if _, ok := res.Resources[pool]; !ok {
+				return res, fmt.Errorf("unknown resource %v", pool)
+			}
+			if capacity == 0 {
+				return res, fmt.Errorf("resource %v has zero capacity", pool)
+			}
+			res.Resources[pool] = capacity
+		}
+		if override.Work == "" {
+			continue
+		}
+		work, ok := res.Works[override.Work]
+		if !ok {
+			return res, fmt.Errorf("unknown work %v", override.Work)
+		}
+		if override.Remove {
+			delete(res.Works, override.Work)
+			for id, other := range res.Works {
+				delete(other.WorksNeedToBeDone, override.Work)
+				res.Works[id] = other
+			}
+			continue
+		}
+		if override.Duration != nil {
+			if len(work.Modes) != 0 {
+				return res, fmt.Errorf("work %v has modes, its duration can't be changed", override.Work)
+			}
+			work.Duration = *override.Duration
+		}
+		if override.ResourceNeeds != nil {
+			if len(work.Modes) != 0 {
+				return res, fmt.Errorf("work %v has modes, its resources can't be changed", override.Work)
+			}
+			work.ResourceNeeds = override.ResourceNeeds
+		}
+		for _, pred := range override.RemoveDependencies {
+			if _, ok := work.WorksNeedToBeDone[pred]; !ok {
+				return res, fmt.Errorf("work %v doesn't need work %v", override.Work, pred)
+			}
+			delete(work.WorksNeedToBeDone, pred)
+		}
+		for pred, dep := range override.AddDependencies {
+			if _, ok := res.Works[pred]; !ok {
+				return res, fmt.Errorf("work %v needs unknown work %v", override.Work, pred)
+			}
+			if err := dep.check(); err != nil {
+				return res, err
+			}
+			work.WorksNeedToBeDone[pred] = dep
+		}
+		res.Works[override.Work] = work
+	}
+	return res, nil
+}
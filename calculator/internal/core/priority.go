@@ -0,0 +1,167 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// priority rules give every work a value, eligible work with the biggest value is scheduled first
+var priorityRules = map[string]func(task *Task) (map[WorkID]float64, error){
+	// latest finish time
+	"lft": func(task *Task) (map[WorkID]float64, error) {
+		path, err := task.FindCriticalPath()
+		if err != nil {
+			return nil, err
+		}
+		priority := make(map[WorkID]float64, len(task.Works))
+		for id, timing := range path.Works {
+			priority[id] = -float64(timing.LatestFinish)
+		}
+		return priority, nil
+	},
+	// minimal total float
+	"mslk": func(task *Task) (map[WorkID]float64, error) {
+		path, err := task.FindCriticalPath()
+		if err != nil {
+			return nil, err
+		}
+		priority := make(map[WorkID]float64, len(task.Works))
+		for id, timing := range path.Works {
+			priority[id] = -float64(timing.TotalFloat)
+		}
+		return priority, nil
+	},
+	// most total successors
+	"mts": func(task *Task) (map[WorkID]float64, error) {
+		successors := successorsOf(task.Works)
+		priority := make(map[WorkID]float64, len(task.Works))
+		for id := range task.Works {
+			visited := make(map[WorkID]struct{})
+			queue := append([]WorkID{}, successors[id]...)
+			for len(queue) > 0 {
+				succ := queue[0]
+				queue = queue[1:]
+				if _, ok := visited[succ]; ok {
+					continue
+				}
+				visited[succ] = struct{}{}
+				queue = append(queue, successors[succ]...)
+			}
+			priority[id] = float64(len(visited))
+		}
+		return priority, nil
+	},
+	// greatest rank positional weight
+	"grpw": func(task *Task) (map[WorkID]float64, error) {
+		successors := successorsOf(task.Works)
+		priority := make(map[WorkID]float64, len(task.Works))
+		for id, work := range task.Works {
+			weight := work.shortest().Duration
+			for _, succ := range successors[id] {
+				weight += task.Works[succ].shortest().Duration
+			}
+			priority[id] = float64(weight)
+		}
+		return priority, nil
+	},
+	// longest remaining path
+	"tail": func(task *Task) (map[WorkID]float64, error) {
+		priority := make(map[WorkID]float64, len(task.Works))
+		for id, tails := range calculateTails(task.Works) {
+			priority[id] = float64(minTail(tails))
+		}
+		return priority, nil
+	},
+	// longest processing time
+	"lpt": func(task *Task) (map[WorkID]float64, error) {
+		priority := make(map[WorkID]float64, len(task.Works))
+		for id, work := range task.Works {
+			priority[id] = float64(work.shortest().Duration)
+		}
+		return priority, nil
+	},
+	// shortest processing time
+	"spt": func(task *Task) (map[WorkID]float64, error) {
+		priority := make(map[WorkID]float64, len(task.Works))
+		for id, work := range task.Works {
+			priority[id] = -float64(work.shortest().Duration)
+		}
+		return priority, nil
+	},
+}
+
+func priorityRuleNames() []string {
+	names := make([]string, 0, len(priorityRules))
+	for name := range priorityRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// prioritySequence builds sequence of works choosing eligible work with the biggest priority
+func prioritySequence(works map[WorkID]Work, priority map[WorkID]float64) []WorkID {
+	sequence := make([]WorkID, 0, len(works))
+	done := make(map[WorkID]struct{}, len(works))
+	for len(sequence) < len(works) {
+		var chosen WorkID
+		found := false
+		for id, work := range works {
+			if _, ok := done[id]; ok || !isAvailable(done, work) {
+				continue
+			}
+			if !found || priority[id] > priority[chosen] || (priority[id] == priority[chosen] && id < chosen) {
+				chosen = id
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+		done[chosen] = struct{}{}
+		sequence = append(sequence, chosen)
+	}
+	return sequence
+}
+
+// priorityScheduler runs serial schedule generation with priority rule heuristics,
+// parameter rule chooses one of them, by default the best of all rules is returned
+type priorityScheduler struct{}
+
+func (priorityScheduler) Schedule(ctx context.Context, task *Task, opts Options) (res Result, err error) {
+	pr, err := newProblem(task)
+	if err != nil {
+		return res, err
+	}
+	rules := priorityRuleNames()
+	if rule := opts.Parameters.String("rule", "all"); rule != "all" {
+		if _, ok := priorityRules[rule]; !ok {
+			return res, fmt.Errorf("%w rule: unknown priority rule %q, known rules: %v", ErrInvalidParameter, rule, rules)
+		}
+		rules = []string{rule}
+	}
+
+	res.LowerBound = pr.lowerBound()
+	report := newReporter(opts)
+	for i, rule := range rules {
+		if i > 0 && res.Schedule.reaches(res.LowerBound) {
+			break
+		}
+		if i > 0 && ctx.Err() != nil {
+			res.Partial = true
+			break
+		}
+		priority, err := priorityRules[rule](task)
+		if err != nil {
+			return res, err
+		}
+		schedule := pr.scheduleSequence(prioritySequence(task.Works, priority))
+		if i == 0 || schedule.better(res.Schedule) {
+			res.Schedule = schedule
+			report.improved(schedule, i+1)
+		}
+	}
+	res.Optimal = res.Schedule.reaches(res.LowerBound)
+	return res, nil
+}
@@ -0,0 +1,158 @@
+package core
+
+import "sort"
+
+// segment is usage of every pool from its start till the start of the next segment
+type segment struct {
+	start int
+	usage []uint
+}
+
+// profile is resource usage of every pool as a step function (skyline),
+// segments are sorted by start and cover time from 0 till length,
+// there is nothing used after length
+type profile struct {
+	pools    []ResourceID
+	length   int
+	segments []segment
+}
+
+func newProfile(pools []ResourceID) *profile {
+	return &profile{pools: pools}
+}
+
+// find returns index of the segment which contains time t < length
+func (p *profile) find(t int) int {
+	return sort.Search(len(p.segments), func(i int) bool { return p.segments[i].start > t }) - 1
+}
+
+// end returns time when the i-th segment ends
+func (p *profile) end(i int) int {
+	if i+1 < len(p.segments) {
+		return p.segments[i+1].start
+	}
+	return p.length
+}
+
+// fits checks whether work with needs can be done from start for duration, if it can't
+// next is the end of the segment where it doesn't fit, so no earlier start fits either
+func (p *profile) fits(capacity []uint, needs []uint, duration uint, start int) (ok bool, next int) {
+	if start >= p.length || duration == 0 {
+		return true, start
+	}
+	for i := p.find(start); i < len(p.segments) && p.segments[i].start < start+int(duration); i++ {
+		for pool, need := range needs {
+			if need != 0 && p.segments[i].usage[pool]+need > capacity[pool] {
+				return false, p.end(i)
+			}
+		}
+	}
+	return true, start
+}
+
+// earliest returns the first time from start when work with needs fits,
+// it jumps over segments instead of trying every time unit
+func (p *profile) earliest(capacity []uint, needs []uint, duration uint, start int) int {
+	for start < p.length {
+		ok, next := p.fits(capacity, needs, duration, start)
+		if ok {
+			break
+		}
+		start = next
+	}
+	return start
+}
+
+// split makes a segment start at time t <= length and returns its index
+func (p *profile) split(t int) int {
+	if t == p.length {
+		return len(p.segments)
+	}
+	i := p.find(t)
+	if p.segments[i].start == t {
+		return i
+	}
+	usage := make([]uint, len(p.pools))
+	copy(usage, p.segments[i].usage)
+	p.segments = append(p.segments, segment{})
+	copy(p.segments[i+2:], p.segments[i+1:])
+	p.segments[i+1] = segment{start: t, usage: usage}
+	return i + 1
+}
+
+func (p *profile) emplace(needs []uint, duration uint, start int) {
+	end := start + int(duration)
+	if p.length < end {
+		p.segments = append(p.segments, segment{start: p.length, usage: make([]uint, len(p.pools))})
+		p.length = end
+	}
+	if duration == 0 {
+		return
+	}
+	first, last := p.split(start), p.split(end)
+	for i := first; i < last; i++ {
+		for pool, need := range needs {
+			p.segments[i].usage[pool] += need
+		}
+	}
+}
+
+// remove undoes emplace of the work, prevLength is the length before it
+func (p *profile) remove(needs []uint, duration uint, start int, prevLength int) {
+	end := start + int(duration)
+	if duration > 0 {
+		// segments may be merged after emplace, so they are split again
+		first, last := p.split(start), p.split(end)
+		for i := first; i < last; i++ {
+			for pool, need := range needs {
+				p.segments[i].usage[pool] -= need
+			}
+		}
+	}
+	p.segments = p.segments[:sort.Search(len(p.segments), func(i int) bool { return p.segments[i].start >= prevLength })]
+	p.length = prevLength
+	p.merge(end)
+	p.merge(start)
+}
+
+// merge joins the segment starting at t with the previous one when their usage is equal
+func (p *profile) merge(t int) {
+	if t <= 0 || t >= p.length {
+		return
+	}
+	i := p.find(t)
+	if p.segments[i].start != t {
+		return
+	}
+	for pool, used := range p.segments[i].usage {
+		if p.segments[i-1].usage[pool] != used {
+			return
+		}
+	}
+	p.segments = append(p.segments[:i], p.segments[i+1:]...)
+}
+
+func (p *profile) copy() *profile {
+	res := &profile{pools: p.pools, length: p.length, segments: make([]segment, len(p.segments))}
+	for i, s := range p.segments {
+		res.segments[i] = segment{start: s.start, usage: append([]uint(nil), s.usage...)}
+	}
+	return res
+}
+
+// usage returns segments of every pool, adjacent segments with equal usage of the pool are joined
+func (p *profile) usage() map[ResourceID][]UsageSegment {
+	res := make(map[ResourceID][]UsageSegment, len(p.pools))
+	for pool, id := range p.pools {
+		segments := make([]UsageSegment, 0, len(p.segments))
+		for i, s := range p.segments {
+			if n := len(segments); n != 0 && segments[n-1].Used == s.usage[pool] {
+				segments[n-1].Finish = uint(p.end(i))
+				continue
+			}
+			segments = append(segments, UsageSegment{Start: uint(s.start), Finish: uint(p.end(i)), Used: s.usage[pool]})
+		}
+		res[id] = segments
+	}
+	return res
+}
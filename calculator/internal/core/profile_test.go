@@ -0,0 +1,106 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testPlacement struct {
+	needs    []uint
+	duration uint
+	start    int
+}
+
+func TestProfileEmplaceRemove(t *testing.T) {
+	tests := []struct {
+		name   string
+		placed []testPlacement
+		// usage of both pools in every time unit after all works are placed
+		usage [][]uint
+	}{
+		{
+			name:   "one work",
+			placed: []testPlacement{{[]uint{1, 2}, 3, 0}},
+			usage:  [][]uint{{1, 1, 1}, {2, 2, 2}},
+		},
+		{
+			name:   "overlapping works",
+			placed: []testPlacement{{[]uint{1, 0}, 4, 0}, {[]uint{2, 1}, 2, 1}, {[]uint{1, 1}, 3, 2}},
+			usage:  [][]uint{{1, 3, 4, 2, 1}, {0, 1, 2, 1, 1}},
+		},
+		{
+			name:   "adjacent works with equal usage",
+			placed: []testPlacement{{[]uint{1, 1}, 2, 0}, {[]uint{1, 1}, 2, 2}, {[]uint{2, 0}, 2, 1}},
+			usage:  [][]uint{{1, 3, 3, 1}, {1, 1, 1, 1}},
+		},
+		{
+			name:   "gap before work",
+			placed: []testPlacement{{[]uint{1, 0}, 1, 0}, {[]uint{0, 3}, 2, 3}},
+			usage:  [][]uint{{1, 0, 0, 0, 0}, {0, 0, 0, 3, 3}},
+		},
+		{
+			name:   "zero duration",
+			placed: []testPlacement{{[]uint{1, 1}, 2, 0}, {[]uint{5, 5}, 0, 1}, {[]uint{5, 5}, 0, 4}},
+			usage:  [][]uint{{1, 1, 0, 0}, {1, 1, 0, 0}},
+		},
+		{
+			// removing the last work merges segments at the end of the previous one
+			name:   "merged boundary",
+			placed: []testPlacement{{[]uint{1, 0}, 2, 2}, {[]uint{1, 0}, 2, 0}, {[]uint{1, 0}, 1, 2}},
+			usage:  [][]uint{{1, 1, 2, 1}, {0, 0, 0, 0}},
+		},
+		{
+			name:   "work inside work",
+			placed: []testPlacement{{[]uint{1, 1}, 5, 0}, {[]uint{1, 0}, 1, 2}, {[]uint{0, 1}, 3, 1}},
+			usage:  [][]uint{{1, 1, 2, 1, 1}, {1, 2, 2, 2, 1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := newProfile([]ResourceID{"cranes", "workers"})
+			before := make([]*profile, 0, len(test.placed))
+			for _, w := range test.placed {
+				before = append(before, p.copy())
+				p.emplace(w.needs, w.duration, w.start)
+				checkProfile(t, p)
+			}
+			usage := p.usage()
+			for pool, id := range p.pools {
+				if got := unitUsage(usage[id]); !reflect.DeepEqual(got, test.usage[pool]) {
+					t.Errorf("usage of %v is %v, expected %v", id, got, test.usage[pool])
+				}
+			}
+			for i := len(test.placed) - 1; i >= 0; i-- {
+				w := test.placed[i]
+				p.remove(w.needs, w.duration, w.start, before[i].length)
+				checkProfile(t, p)
+				if p.length != before[i].length || !reflect.DeepEqual(p.usage(), before[i].usage()) {
+					t.Errorf("removing work %v gives %v, expected %v", i, p.segments, before[i].segments)
+				}
+			}
+		})
+	}
+}
+
+// checkProfile checks that segments are sorted and cover time from 0 till length
+func checkProfile(t *testing.T, p *profile) {
+	t.Helper()
+	for i, s := range p.segments {
+		if i == 0 && s.start != 0 || i > 0 && s.start <= p.segments[i-1].start || s.start >= p.length {
+			t.Fatalf("invalid segments %v of length %v", p.segments, p.length)
+		}
+	}
+	if p.length > 0 && len(p.segments) == 0 {
+		t.Fatalf("no segments of length %v", p.length)
+	}
+}
+
+func unitUsage(segments []UsageSegment) []uint {
+	var res []uint
+	for _, s := range segments {
+		for t := s.Start; t < s.Finish; t++ {
+			res = append(res, s.Used)
+		}
+	}
+	return res
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// separates task name from work name in works of combined tasks,
+// task names can't contain it since they are a part of the url
+const projectSeparator = "/"
+
+// ProjectWorkID is id of the work of the task in the combined task
+func ProjectWorkID(task string, work WorkID) WorkID {
+	return WorkID(task + projectSeparator + string(work))
+}
+
+// SplitProjectWorkID returns task name and work id of the work of the combined task
+func SplitProjectWorkID(id WorkID) (task string, work WorkID) {
+	parts := strings.SplitN(string(id), projectSeparator, 2)
+	if len(parts) < 2 {
+		return "", id
+	}
+	return parts[0], WorkID(parts[1])
+}
+
+type Project struct {
+	Time  uint                     `json:"time"`
+	Works map[WorkID]ScheduledWork `json:"works"`
+	// total lateness of the works of the task
+	Lateness uint `json:"lateness"`
+}
+
+// CombineTasks makes one task of works of all tasks, so they compete for the same
+// resource pools. Capacity of a pool is taken from capacity or it's the largest
+// capacity of the pool among the tasks, capacity can set only pools of the tasks. All tasks start at time 0 of the combined task.
+func CombineTasks(tasks []Task, capacity map[ResourceID]uint) (Task, error) {
+	names := make([]string, 0, len(tasks))
+	combined := Task{
+		Resources: make(map[ResourceID]uint),
+		Works:     make(map[WorkID]Work),
+	}
+	for _, task := range tasks {
+		if strings.Contains(task.Name, projectSeparator) {
+			return combined, fmt.Errorf("task name %q can't contain %q", task.Name, projectSeparator)
+		}
+		for _, name := range names {
+			if name == task.Name {
+				return combined, fmt.Errorf("task %v is listed twice", task.Name)
+			}
+		}
+		names = append(names, task.Name)
+
+		for pool, amount := range task.Resources {
+			if amount > combined.Resources[pool] {
+				combined.Resources[pool] = amount
+			}
+		}
+		for id, work := range task.Works {
+			work.Name = ProjectWorkID(task.Name, id)
+			needs := make(map[WorkID]Dependency, len(work.WorksNeedToBeDone))
+			for pred, dep := range work.WorksNeedToBeDone {
+				needs[ProjectWorkID(task.Name, pred)] = dep
+			}
+			work.WorksNeedToBeDone = needs
+			combined.Works[work.Name] = work
+		}
+	}
+	for pool, amount := range capacity {
+		if _, ok := combined.Resources[pool]; !ok {
+			return combined, fmt.Errorf("unknown resource %v", pool)
+		}
+		if amount == 0 {
+			return combined, fmt.Errorf("resource %v has zero capacity", pool)
+		}
+		combined.Resources[pool] = amount
+	}
+	for id, work := range combined.Works {
+		for _, workMode := range work.modes() {
+			for pool, need := range workMode.ResourceNeeds {
+				if need > combined.Resources[pool] {
+					return combined, fmt.Errorf("work %v needs %v of %v, more than shared capacity %v", id, need, pool, combined.Resources[pool])
+				}
+			}
+		}
+	}
+	combined.Name = strings.Join(names, "+")
+	return combined, nil
+}
+
+// SplitProjects returns schedules of every task of the combined task
+func (s Schedule) SplitProjects() map[string]Project {
+	projects := make(map[string]Project)
+	for id, work := range s.Works {
+		name, workID := SplitProjectWorkID(id)
+		project, ok := projects[name]
+		if !ok {
+			project.Works = make(map[WorkID]ScheduledWork)
+		}
+		project.Works[workID] = work
+		if work.Finish > project.Time {
+			project.Time = work.Finish
+		}
+		project.Lateness += s.Late[id]
+		projects[name] = project
+	}
+	return projects
+}
@@ -0,0 +1,235 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	AutoStrategy      = "auto"
+	SamplingStrategy  = "sampling"
+	PriorityStrategy  = "priority"
+	GeneticStrategy   = "genetic"
+	AnnealingStrategy = "annealing"
+	ExactStrategy     = "exact"
+)
+
+// AlgorithmVersion changes every time the same seed may start giving different results
+const AlgorithmVersion = "1.2.0"
+
+// tasks bigger than this are calculated by auto strategy only with random sampling
+const exactSearchMaxWorks = 30
+
+var ErrInvalidParameter = errors.New("invalid parameter")
+var ErrUnknownStrategy = errors.New("unknown strategy")
+
+type Result struct {
+	Schedule Schedule
+	Optimal  bool
+	// calculation was stopped by context before it was finished
+	Partial bool
+	// no schedule of the task is shorter than this
+	LowerBound uint
+}
+
+// Gap is how much longer the schedule is than the lower bound, relative to the bound,
+// deadlines are not taken into account
+func (res Result) Gap() float64 {
+	if res.LowerBound == 0 {
+		return 0
+	}
+	return float64(res.Schedule.Time-res.LowerBound) / float64(res.LowerBound)
+}
+
+type Options struct {
+	Parameters Parameters
+	// all random choices of strategies are made from sources derived from the seed
+	Seed int64
+	// Progress is called every time the strategy finds a schedule better than before,
+	// it may be nil
+	Progress func(Progress)
+}
+
+type Progress struct {
+	Schedule Schedule
+	// number of schedules (or search nodes) the strategy has checked so far
+	Iteration int
+}
+
+// reporter passes to Progress only schedules better than all reported before
+type reporter struct {
+	progress func(Progress)
+	reported bool
+	best     Schedule
+}
+
+func newReporter(opts Options) *reporter {
+	return &reporter{progress: opts.Progress}
+}
+
+func (r *reporter) improved(schedule Schedule, iteration int) {
+	if r.progress == nil || (r.reported && !schedule.better(r.best)) {
+		return
+	}
+	r.reported = true
+	r.best = schedule
+	r.progress(Progress{Schedule: schedule, Iteration: iteration})
+}
+
+// Scheduler is a strategy of searching for the shortest schedule of a task.
+// When ctx is done it should stop and return the best schedule found so far
+// marked as partial, or the context error if nothing was found.
+type Scheduler interface {
+	Schedule(ctx context.Context, task *Task, opts Options) (Result, error)
+}
+
+// DeriveSeed returns seed of the independent random source number stream (splitmix64)
+func DeriveSeed(seed int64, stream int) int64 {
+	z := uint64(seed) + uint64(stream+1)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+var (
+	schedulersMutex sync.RWMutex
+	schedulers      = map[string]Scheduler{
+		AutoStrategy:      autoScheduler{},
+		SamplingStrategy:  samplingScheduler{},
+		PriorityStrategy:  priorityScheduler{},
+		GeneticStrategy:   geneticScheduler{},
+		AnnealingStrategy: annealingScheduler{},
+		ExactStrategy:     exactScheduler{},
+	}
+)
+
+func RegisterScheduler(name string, scheduler Scheduler) {
+	schedulersMutex.Lock()
+	defer schedulersMutex.Unlock()
+	schedulers[name] = scheduler
+}
+
+// GetScheduler returns registered strategy, empty name means auto strategy
+func GetScheduler(name string) (Scheduler, error) {
+	if name == "" {
+		name = AutoStrategy
+	}
+	schedulersMutex.RLock()
+	defer schedulersMutex.RUnlock()
+	scheduler, ok := schedulers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, known strategies: %v", ErrUnknownStrategy, name, schedulerNames())
+	}
+	return scheduler, nil
+}
+
+func schedulerNames() []string {
+	names := make([]string, 0, len(schedulers))
+	for name := range schedulers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parameters are strategy specific settings, missing values are replaced by defaults
+type Parameters map[string]string
+
+func (p Parameters) Int(name string, def int) (int, error) {
+	value, ok := p[name]
+	if !ok || value == "" {
+		return def, nil
+	}
+	res, err := strconv.Atoi(value)
+	if err != nil || res <= 0 {
+		return 0, fmt.Errorf("%w %v: expected positive integer, got %q", ErrInvalidParameter, name, value)
+	}
+	return res, nil
+}
+
+func (p Parameters) Float(name string, def float64) (float64, error) {
+	value, ok := p[name]
+	if !ok || value == "" {
+		return def, nil
+	}
+	res, err := strconv.ParseFloat(value, 64)
+	if err != nil || res <= 0 {
+		return 0, fmt.Errorf("%w %v: expected positive number, got %q", ErrInvalidParameter, name, value)
+	}
+	return res, nil
+}
+
+func (p Parameters) String(name string, def string) string {
+	if value, ok := p[name]; ok && value != "" {
+		return value
+	}
+	return def
+}
+
+type samplingScheduler struct{}
+
+func (samplingScheduler) Schedule(ctx context.Context, task *Task, opts Options) (res Result, err error) {
+	iterations, err := opts.Parameters.Int("iterations", 1000*1000)
+	if err != nil {
+		return res, err
+	}
+	// one worker per processor by default
+	workers, err := opts.Parameters.Int("goroutines", runtime.GOMAXPROCS(0))
+	if err != nil {
+		return res, err
+	}
+	return task.StartCalculation(ctx, iterations, workers, opts)
+}
+
+type exactScheduler struct{}
+
+func (exactScheduler) Schedule(ctx context.Context, task *Task, opts Options) (res Result, err error) {
+	budget, err := opts.Parameters.Int("budget", 1000*1000)
+	if err != nil {
+		return res, err
+	}
+	return task.FindOptimalTime(ctx, budget, opts)
+}
+
+// autoScheduler runs exact search for small tasks and falls back to random sampling
+type autoScheduler struct{}
+
+func (autoScheduler) Schedule(ctx context.Context, task *Task, opts Options) (res Result, err error) {
+	report := newReporter(opts)
+	opts.Progress = func(p Progress) {
+		report.improved(p.Schedule, p.Iteration)
+	}
+	searched := false
+	if len(task.Works) <= exactSearchMaxWorks {
+		res, err = exactScheduler{}.Schedule(ctx, task, opts)
+		if err != nil {
+			return res, err
+		}
+		if res.Optimal || res.Partial {
+			return res, nil
+		}
+		searched = true
+	}
+	sampled, err := samplingScheduler{}.Schedule(ctx, task, opts)
+	if err != nil {
+		if searched && ctx.Err() != nil {
+			res.Partial = true
+			return res, nil
+		}
+		return res, err
+	}
+	if !searched || sampled.Schedule.better(res.Schedule) {
+		res.Schedule = sampled.Schedule
+	}
+	if sampled.LowerBound > res.LowerBound {
+		res.LowerBound = sampled.LowerBound
+	}
+	res.Optimal = res.Schedule.reaches(res.LowerBound)
+	res.Partial = sampled.Partial
+	return res, nil
+}
@@ -0,0 +1,203 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	PertDistribution       = "pert"
+	TriangularDistribution = "triangular"
+)
+
+// number of histogram bins of simulated makespans
+const histogramBins = 20
+
+// MaxSamples limits number of samples of one simulation
+const MaxSamples = 100 * 1000
+
+// Estimate is three-point estimate of a work duration
+type Estimate struct {
+	Optimistic  uint `json:"optimistic" bson:"optimistic"`
+	MostLikely  uint `json:"most_likely" bson:"most_likely"`
+	Pessimistic uint `json:"pessimistic" bson:"pessimistic"`
+}
+
+func (e Estimate) check() error {
+	if e.Optimistic > e.MostLikely || e.MostLikely > e.Pessimistic {
+		return fmt.Errorf("expected optimistic <= most likely <= pessimistic, got %v, %v, %v", e.Optimistic, e.MostLikely, e.Pessimistic)
+	}
+	return nil
+}
+
+// sample returns random duration from triangular or beta-PERT distribution
+func (e Estimate) sample(rng *rand.Rand, distribution string) uint {
+	a, m, b := float64(e.Optimistic), float64(e.MostLikely), float64(e.Pessimistic)
+	if a == b {
+		return e.MostLikely
+	}
+	var value float64
+	if distribution == TriangularDistribution {
+		u := rng.Float64()
+		if u < (m-a)/(b-a) {
+			value = a + math.Sqrt(u*(b-a)*(m-a))
+		} else {
+			value = b - math.Sqrt((1-u)*(b-a)*(b-m))
+		}
+	} else {
+		alpha := 1 + 4*(m-a)/(b-a)
+		beta := 1 + 4*(b-m)/(b-a)
+		x := gamma(rng, alpha)
+		value = a + (b-a)*x/(x+gamma(rng, beta))
+	}
+	return uint(math.Round(value))
+}
+
+// gamma returns random value of gamma distribution with the shape >= 1 (Marsaglia and Tsang)
+func gamma(rng *rand.Rand, shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := rng.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		if u := rng.Float64(); math.Log(u) < 0.5*x*x+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+type SimulationOptions struct {
+	Samples      int
+	Distribution string
+	// Scheduler schedules every sample with Options, seed of the sample is derived from its seed
+	Scheduler Scheduler
+	Options   Options
+}
+
+type HistogramBin struct {
+	// makespans from From up to To, not including To
+	From  uint `json:"from"`
+	To    uint `json:"to"`
+	Count int  `json:"count"`
+}
+
+type Simulation struct {
+	// sorted makespans of all samples
+	Makespans []uint `json:"makespans"`
+	// simulation was stopped by context before all samples were scheduled
+	Partial bool `json:"partial"`
+}
+
+// Percentile returns makespan which isn't exceeded in p percents of samples
+func (s Simulation) Percentile(p float64) uint {
+	if len(s.Makespans) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(s.Makespans)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return s.Makespans[rank]
+}
+
+func (s Simulation) Mean() float64 {
+	if len(s.Makespans) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, makespan := range s.Makespans {
+		sum += float64(makespan)
+	}
+	return sum / float64(len(s.Makespans))
+}
+
+// Histogram returns bins of equal width from the least makespan to the largest one,
+// bins without samples are included with zero count
+func (s Simulation) Histogram() []HistogramBin {
+	if len(s.Makespans) == 0 {
+		return nil
+	}
+	min, max := s.Makespans[0], s.Makespans[len(s.Makespans)-1]
+	width := (max - min + histogramBins) / histogramBins
+	bins := make([]HistogramBin, (max-min)/width+1)
+	for i := range bins {
+		bins[i].From = min + uint(i)*width
+		bins[i].To = bins[i].From + width
+	}
+	for _, makespan := range s.Makespans {
+		bins[(makespan-min)/width].Count++
+	}
+	return bins
+}
+
+// Simulate schedules the task with durations sampled from estimates of its works,
+// works without estimates and works with modes keep their durations.
+// Durations of the i-th sample and its schedule are random with seeds
+// derived from the seed of options and i.
+func (task *Task) Simulate(ctx context.Context, opts SimulationOptions) (res Simulation, err error) {
+	if opts.Distribution != "" && opts.Distribution != PertDistribution && opts.Distribution != TriangularDistribution {
+		return res, fmt.Errorf("%w distribution: expected %v or %v, got %q", ErrInvalidParameter, PertDistribution, TriangularDistribution, opts.Distribution)
+	}
+	if opts.Samples < 0 || opts.Samples > MaxSamples {
+		return res, fmt.Errorf("%w samples: expected from 0 up to %v, got %v", ErrInvalidParameter, MaxSamples, opts.Samples)
+	}
+	ids := make([]WorkID, 0, len(task.Works))
+	for id, work := range task.Works {
+		if work.Estimate == nil {
+			continue
+		}
+		if err = work.Estimate.check(); err != nil {
+			return res, fmt.Errorf("work %v has invalid estimate: %v", id, err)
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for i := 0; i < opts.Samples; i++ {
+		if ctx.Err() != nil {
+			res.Partial = true
+			break
+		}
+		seed := DeriveSeed(opts.Options.Seed, i)
+		rng := rand.New(rand.NewSource(seed))
+		sampled := *task
+		sampled.Works = make(map[WorkID]Work, len(task.Works))
+		for id, work := range task.Works {
+			sampled.Works[id] = work
+		}
+		for _, id := range ids {
+			work := sampled.Works[id]
+			work.Duration = work.Estimate.sample(rng, opts.Distribution)
+			sampled.Works[id] = work
+		}
+
+		schedulerOpts := opts.Options
+		schedulerOpts.Seed = seed
+		schedulerOpts.Progress = nil
+		result, err := opts.Scheduler.Schedule(ctx, &sampled, schedulerOpts)
+		if err != nil {
+			if ctx.Err() != nil {
+				res.Partial = true
+				break
+			}
+			return res, err
+		}
+		if result.Partial {
+			res.Partial = true
+			break
+		}
+		res.Makespans = append(res.Makespans, result.Schedule.Time)
+	}
+	if len(res.Makespans) == 0 && opts.Samples > 0 {
+		return res, ctx.Err()
+	}
+	sort.Slice(res.Makespans, func(i, j int) bool { return res.Makespans[i] < res.Makespans[j] })
+	return res, nil
+}
@@ -0,0 +1,173 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	PrecedenceViolation    = "precedence"
+	EarliestStartViolation = "earliest_start"
+	CapacityViolation      = "capacity"
+	DeadlineViolationKind  = "deadline"
+)
+
+// PlannedWork is start of the work in a schedule proposed by user,
+// Mode is required only for works with modes
+type PlannedWork struct {
+	Start uint   `json:"start"`
+	Mode  string `json:"mode,omitempty"`
+}
+
+// Violation is a broken constraint of a proposed schedule during time period
+// from From up to To, not including To.
+// For precedence the first work is the needed one and the second starts too early,
+// for capacity Used of Resource is more than Capacity and Works are done at that time.
+type Violation struct {
+	Kind     string     `json:"kind"`
+	Works    []WorkID   `json:"works"`
+	Resource ResourceID `json:"resource,omitempty"`
+	From     uint       `json:"from"`
+	To       uint       `json:"to"`
+	Used     uint       `json:"used,omitempty"`
+	Capacity uint       `json:"capacity,omitempty"`
+}
+
+// ValidateSchedule checks dependencies, earliest starts, deadlines and resource
+// capacity of the proposed schedule which must have start of every work of the task.
+// It returns the schedule and all its violations, error is returned for invalid input.
+func (task *Task) ValidateSchedule(planned map[WorkID]PlannedWork) (Schedule, []Violation, error) {
+	pr, err := newProblem(task)
+	if err != nil {
+		return Schedule{}, nil, err
+	}
+	for id := range planned {
+		if _, ok := task.Works[id]; !ok {
+			return Schedule{}, nil, fmt.Errorf("unknown work %v", id)
+		}
+	}
+
+	resources := newProfile(pr.pools)
+	placed := make(map[WorkID]ScheduledWork, len(pr.ids))
+	chosen := make(map[WorkID]mode, len(pr.ids))
+	for _, id := range pr.ids {
+		plan, ok := planned[id]
+		if !ok {
+			return Schedule{}, nil, fmt.Errorf("no start of work %v", id)
+		}
+		m, err := pr.findMode(id, plan.Mode)
+		if err != nil {
+			return Schedule{}, nil, err
+		}
+		if plan.Start > maxTime || m.duration > maxTime-plan.Start {
+			return Schedule{}, nil, fmt.Errorf("work %v finishes after max time %v", id, uint(maxTime))
+		}
+		resources.emplace(m.needs, m.duration, int(plan.Start))
+		placed[id] = ScheduledWork{Start: plan.Start, Finish: plan.Start + m.duration, Mode: m.name}
+		chosen[id] = m
+	}
+	schedule := Schedule{Time: uint(resources.length), Works: placed, profile: resources}
+	pr.checkDeadlines(&schedule)
+
+	var violations []Violation
+	for _, id := range pr.ids {
+		work, scheduled := task.Works[id], placed[id]
+		if release := work.EarliestStart; release != nil && scheduled.Start < *release {
+			violations = append(violations, Violation{
+				Kind:  EarliestStartViolation,
+				Works: []WorkID{id},
+				From:  scheduled.Start,
+				To:    *release,
+			})
+		}
+		for pred, dep := range work.WorksNeedToBeDone {
+			predScheduled := placed[pred]
+			required := int(predScheduled.Finish) + dep.shift(chosen[pred].duration, chosen[id].duration)
+			if int(scheduled.Start) < required {
+				violations = append(violations, Violation{
+					Kind:  PrecedenceViolation,
+					Works: []WorkID{pred, id},
+					From:  scheduled.Start,
+					To:    uint(required),
+				})
+			}
+		}
+		if late, ok := schedule.Late[id]; ok {
+			violations = append(violations, Violation{
+				Kind:  DeadlineViolationKind,
+				Works: []WorkID{id},
+				From:  scheduled.Finish - late,
+				To:    scheduled.Finish,
+			})
+		}
+	}
+	violations = append(violations, pr.capacityViolations(resources, placed, chosen)...)
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].From != violations[j].From {
+			return violations[i].From < violations[j].From
+		}
+		return violations[i].Kind < violations[j].Kind
+	})
+	return schedule, violations, nil
+}
+
+// findMode returns mode of the work by its name, works without modes have only the unnamed one
+func (pr *problem) findMode(id WorkID, name string) (mode, error) {
+	modes := pr.modes[id]
+	for _, m := range modes {
+		if m.name == name {
+			return m, nil
+		}
+	}
+	if len(modes) == 1 && modes[0].name == "" {
+		return mode{}, fmt.Errorf("work %v has no modes, got mode %q", id, name)
+	}
+	names := make([]string, 0, len(modes))
+	for _, m := range modes {
+		names = append(names, m.name)
+	}
+	return mode{}, fmt.Errorf("work %v has no mode %q, expected one of %v", id, name, names)
+}
+
+// capacityViolations joins adjacent segments of the profile where a pool is overloaded
+// into time periods and finds the works done during them
+func (pr *problem) capacityViolations(resources *profile, placed map[WorkID]ScheduledWork, chosen map[WorkID]mode) []Violation {
+	var violations []Violation
+	for pool, capacity := range pr.capacity {
+		var current *Violation
+		for i, s := range resources.segments {
+			used := s.usage[pool]
+			if used <= capacity {
+				current = nil
+				continue
+			}
+			if current == nil {
+				violations = append(violations, Violation{
+					Kind:     CapacityViolation,
+					Resource: pr.pools[pool],
+					From:     uint(s.start),
+					Capacity: capacity,
+				})
+				current = &violations[len(violations)-1]
+			}
+			current.To = uint(resources.end(i))
+			if used > current.Used {
+				current.Used = used
+			}
+		}
+	}
+	for i := range violations {
+		for _, id := range pr.ids {
+			work := placed[id]
+			if chosen[id].needs[pr.index(violations[i].Resource)] > 0 &&
+				work.Start < violations[i].To && violations[i].From < work.Finish {
+				violations[i].Works = append(violations[i].Works, id)
+			}
+		}
+	}
+	return violations
+}
+
+func (pr *problem) index(pool ResourceID) int {
+	return sort.Search(len(pr.pools), func(i int) bool { return pr.pools[i] >= pool })
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"calculator/internal/core"
+	pb "calculator/pkg/calculator_pb"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requestTask returns task of the calculation: the definition from the request
+// or the stored task, with overrides of the request applied
+func (s *Service) requestTask(in *pb.CalculateRequest) (task core.Task, err error) {
+	if in.GetDefinition() != nil {
+		task, err = taskFromPb(in.GetDefinition())
+		if err != nil {
+			return task, status.Errorf(codes.InvalidArgument, "invalid task definition: %v", err)
+		}
+		if task.Name == "" {
+			task.Name = in.GetTask()
+		}
+	} else {
+		task, err = s.tasks.Get(in.GetTask())
+		if err != nil {
+			return task, fmt.Errorf("can't get task due to %v", err)
+		}
+	}
+	if len(in.GetOverrides()) != 0 {
+		task, err = task.WithOverrides(overridesFromPb(in.GetOverrides()))
+		if err != nil {
+			return task, status.Errorf(codes.InvalidArgument, "can't apply overrides: %v", err)
+		}
+	}
+	return task, checkTask(task)
+}
+
+func taskFromPb(def *pb.TaskDefinition) (core.Task, error) {
+	task := core.Task{
+		Name:      def.GetName(),
+		StartDate: def.GetStartDate(),
+		Resources: resourcesFromPb(def.GetResources()),
+		Works:     make(map[core.WorkID]core.Work, len(def.GetWorks())),
+	}
+	for pool, capacity := range task.Resources {
+		if capacity == 0 {
+			return task, fmt.Errorf("resource %v has zero capacity", pool)
+		}
+	}
+	if calendar := def.GetCalendar(); calendar != nil {
+		task.Calendar = &core.Calendar{
+			WorkingDays: calendar.GetWorkingDays(),
+			Holidays:    calendar.GetHolidays(),
+			Unit:        calendar.GetUnit(),
+			UnitsPerDay: uint(calendar.GetUnitsPerDay()),
+		}
+	}
+	for _, w := range def.GetWorks() {
+		id := core.WorkID(w.GetName())
+		if _, ok := task.Works[id]; ok || id == "" {
+			return task, fmt.Errorf("work name %q is empty or repeated", id)
+		}
+		work := core.Work{
+			Name:              id,
+			Duration:          uint(w.GetDuration()),
+			ResourceNeeds:     resourcesFromPb(w.GetResources()),
+			WorksNeedToBeDone: dependenciesFromPb(w.GetNeeds()),
+		}
+		for _, m := range w.GetModes() {
+			work.Modes = append(work.Modes, core.Mode{
+				Name:          m.GetName(),
+				Duration:      uint(m.GetDuration()),
+				ResourceNeeds: resourcesFromPb(m.GetResources()),
+			})
+		}
+		if w.EarliestStart != nil {
+			release := uint(w.GetEarliestStart())
+			work.EarliestStart = &release
+		}
+		if w.Deadline != nil {
+			deadline := uint(w.GetDeadline())
+			work.Deadline = &deadline
+		}
+		if e := w.GetEstimate(); e != nil {
+			work.Estimate = &core.Estimate{
+				Optimistic:  uint(e.GetOptimistic()),
+				MostLikely:  uint(e.GetMostLikely()),
+				Pessimistic: uint(e.GetPessimistic()),
+			}
+		}
+		task.Works[id] = work
+	}
+	return task, nil
+}
+
+func overridesFromPb(overrides []*pb.TaskOverride) []core.Override {
+	res := make([]core.Override, 0, len(overrides))
+	for _, o := range overrides {
+		override := core.Override{
+			Work:            core.WorkID(o.GetWork()),
+			Remove:          o.GetRemove(),
+			AddDependencies: dependenciesFromPb(o.GetAddDependencies()),
+			Capacity:        resourcesFromPb(o.GetCapacity()),
+		}
+		if o.Duration != nil {
+			duration := uint(o.GetDuration())
+			override.Duration = &duration
+		}
+		if len(o.GetResources()) != 0 {
+			override.ResourceNeeds = resourcesFromPb(o.GetResources())
+		}
+		for _, pred := range o.GetRemoveDependencies() {
+			override.RemoveDependencies = append(override.RemoveDependencies, core.WorkID(pred))
+		}
+		res = append(res, override)
+	}
+	return res
+}
+
+func resourcesFromPb(resources map[string]uint64) map[core.ResourceID]uint {
+	res := make(map[core.ResourceID]uint, len(resources))
+	for pool, amount := range resources {
+		res[core.ResourceID(pool)] = uint(amount)
+	}
+	return res
+}
+
+func dependenciesFromPb(deps []*pb.Dependency) map[core.WorkID]core.Dependency {
+	res := make(map[core.WorkID]core.Dependency, len(deps))
+	for _, dep := range deps {
+		res[core.WorkID(dep.GetWork())] = core.Dependency{
+			Type: core.DependencyType(dep.GetType()),
+			Lag:  int(dep.GetLag()),
+		}
+	}
+	return res
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	pb "calculator/pkg/calculator_pb"
+	"context"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// flight is a calculation shared by all callers waiting for it
+type flight struct {
+	done    chan struct{}
+	res     *pb.CalculateResponse
+	err     error
+	waiters int
+	// the calculation stops by the deadline of the caller started it, zero is no deadline
+	deadline time.Time
+	cancel   context.CancelFunc
+}
+
+// flights coalesces concurrent calculations with the same key
+type flights struct {
+	mutex sync.Mutex
+	calls map[string]*flight
+}
+
+func newFlights() *flights {
+	return &flights{calls: make(map[string]*flight)}
+}
+
+// do returns result of the calculation in flight with the key or starts calc.
+// The calculation doesn't depend on context of any caller, every caller stops
+// waiting when its ctx is done and the calculation is cancelled when nobody waits.
+// Caller joins only calculation stopping by its deadline, so it gets partial result in time,
+// and without deadline only calculation without deadline, otherwise it calculates alone.
+func (f *flights) do(ctx context.Context, key string, calc func(context.Context) (*pb.CalculateResponse, error)) (*pb.CalculateResponse, error) {
+	deadline, hasDeadline := ctx.Deadline()
+	f.mutex.Lock()
+	call, ok := f.calls[key]
+	if ok && (hasDeadline == call.deadline.IsZero() || hasDeadline && call.deadline.After(deadline)) {
+		f.mutex.Unlock()
+		return calc(ctx)
+	}
+	if !ok {
+		call = &flight{done: make(chan struct{})}
+		callCtx, cancel := context.WithCancel(context.Background())
+		if hasDeadline {
+			call.deadline = deadline
+			callCtx, cancel = context.WithDeadline(context.Background(), deadline)
+		}
+		call.cancel = cancel
+		f.calls[key] = call
+		go f.run(callCtx, key, call, calc)
+	}
+	call.waiters++
+	f.mutex.Unlock()
+
+	select {
+	case <-call.done:
+		return call.res, call.err
+	case <-ctx.Done():
+		f.mutex.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			f.forget(key, call)
+		}
+		f.mutex.Unlock()
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+func (f *flights) run(ctx context.Context, key string, call *flight, calc func(context.Context) (*pb.CalculateResponse, error)) {
+	defer call.cancel()
+	res, err := calc(ctx)
+	f.mutex.Lock()
+	f.forget(key, call)
+	f.mutex.Unlock()
+	call.res, call.err = res, err
+	close(call.done)
+}
+
+// forget removes the call if it's still in flight with the key, mutex must be locked
+func (f *flights) forget(key string, call *flight) {
+	if f.calls[key] == call {
+		delete(f.calls, key)
+	}
+}
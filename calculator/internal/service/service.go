@@ -1,57 +1,547 @@
 package service
 
 import (
+	"calculator/internal/cache"
 	"calculator/internal/core"
 	pb "calculator/pkg/calculator_pb"
 	"context"
+	"errors"
 	"fmt"
-	"github.com/go-redis/redis"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"log"
-	"strconv"
+	"sort"
+	"strings"
 	"time"
 )
 
+// calculation stops this long before the client deadline to have time to send partial result
+const deadlineMargin = 100 * time.Millisecond
+
+// cached results depend only on content of the task, so they don't go stale after edits
+const cacheTTL = 7 * 24 * time.Hour
+
 type taskGetter interface {
 	Get(taskName string) (task core.Task, err error)
 }
 
 type Service struct {
 	pb.UnimplementedCalculatorServer
-	tasks       taskGetter
-	clientRedis *redis.Client
+	tasks   taskGetter
+	cache   *cache.Counted
+	flights *flights
 }
 
-func NewService(tGetter taskGetter, redis *redis.Client) (*Service, error) {
+func NewService(tGetter taskGetter, c cache.Cache) (*Service, error) {
 
 	return &Service{
-		tasks:       tGetter,
-		clientRedis: redis,
+		tasks:   tGetter,
+		cache:   cache.WithStats(c),
+		flights: newFlights(),
 	}, nil
 }
 
+func (s *Service) CacheStats(ctx context.Context, in *pb.CacheStatsRequest) (*pb.CacheStatsResponse, error) {
+	hits, misses := s.cache.Stats()
+	return &pb.CacheStatsResponse{Hits: hits, Misses: misses}, nil
+}
+
+// getTask returns stored task or InvalidArgument status if the task can't be scheduled
+func (s *Service) getTask(taskName string) (task core.Task, err error) {
+	task, err = s.tasks.Get(taskName)
+	if err != nil {
+		return task, fmt.Errorf("can't get task due to %v", err)
+	}
+	return task, checkTask(task)
+}
+
+// checkTask returns InvalidArgument status if the task can't be scheduled
+func checkTask(task core.Task) error {
+	if err := task.Check(); err != nil {
+		return status.Errorf(codes.InvalidArgument, "task %v is invalid: %v", task.Name, err)
+	}
+	return nil
+}
+
+// calculationContext is cancelled with ctx and stops a bit before its deadline
+func calculationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline.Add(-deadlineMargin))
+	}
+	return context.WithCancel(ctx)
+}
+
+// requestSeed returns seed of the request or a new one when it's not set
+func requestSeed(seed *int64) int64 {
+	if seed != nil {
+		return *seed
+	}
+	return time.Now().UnixNano()
+}
+
+// calculationError returns status of the calculation error: InvalidArgument for invalid
+// parameters, cancellation or deadline of the call, or nil when the result can be sent
+func calculationError(ctx context.Context, calcCtx context.Context, err error) error {
+	if errors.Is(err, core.ErrInvalidParameter) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	if err != nil {
+		if calcCtx.Err() != nil {
+			return status.FromContextError(calcCtx.Err()).Err()
+		}
+		return fmt.Errorf("can't complete calculation due to %v", err)
+	}
+	return nil
+}
+
+// cacheKey identifies calculation by fingerprint of the task, algorithm version,
+// strategy and its parameters, so tasks with the same content share results
+func cacheKey(task core.Task, in *pb.CalculateRequest) (string, error) {
+	fingerprint, err := task.Fingerprint()
+	if err != nil {
+		return "", err
+	}
+	strategy := in.GetStrategy()
+	if strategy == "" {
+		strategy = core.AutoStrategy
+	}
+	names := make([]string, 0, len(in.GetParameters()))
+	for name := range in.GetParameters() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	key := strings.Builder{}
+	// v2 responses have resource usage as segments
+	key.WriteString("calculation.v2|")
+	key.WriteString(core.AlgorithmVersion)
+	key.WriteString("|")
+	key.WriteString(fingerprint)
+	key.WriteString("|")
+	key.WriteString(strategy)
+	if in.Seed != nil {
+		key.WriteString(fmt.Sprintf("|seed=%v", in.GetSeed()))
+	}
+	for _, name := range names {
+		key.WriteString(fmt.Sprintf("|%v=%v", name, in.GetParameters()[name]))
+	}
+	return key.String(), nil
+}
+
 func (s *Service) Calculate(ctx context.Context, in *pb.CalculateRequest) (*pb.CalculateResponse, error) {
-	targetTaskName := in.GetTask()
-	ansStr, err := s.clientRedis.Get(targetTaskName).Result()
-	if err == nil {
-		res, _ := strconv.ParseUint(ansStr, 10, 32)
-		return &pb.CalculateResponse{Time: res}, nil
+	return s.calculate(ctx, in, nil)
+}
 
+// CalculateStream sends every schedule shorter than found before and the result in the end
+func (s *Service) CalculateStream(in *pb.CalculateRequest, stream pb.Calculator_CalculateStreamServer) error {
+	started := time.Now()
+	var sendErr error
+	res, err := s.calculate(stream.Context(), in, func(progress core.Progress) {
+		if sendErr != nil {
+			return
+		}
+		sendErr = stream.Send(&pb.CalculateProgress{
+			Time:      uint64(progress.Schedule.Time),
+			Iteration: uint64(progress.Iteration),
+			ElapsedMs: uint64(time.Since(started).Milliseconds()),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if sendErr != nil {
+		return fmt.Errorf("can't send calculation progress due to %v", sendErr)
 	}
+	return stream.Send(&pb.CalculateProgress{
+		Time:      res.GetTime(),
+		ElapsedMs: uint64(time.Since(started).Milliseconds()),
+		Result:    res,
+	})
+}
 
-	task, err := s.tasks.Get(targetTaskName)
+// calculate runs strategy of the request, progress is called on every improvement and may be nil
+func (s *Service) calculate(ctx context.Context, in *pb.CalculateRequest, progress func(core.Progress)) (*pb.CalculateResponse, error) {
+	scheduler, err := core.GetScheduler(in.GetStrategy())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
 
+	task, err := s.requestTask(in)
 	if err != nil {
-		return nil, fmt.Errorf("can't get task due to %v", err)
+		return nil, err
 	}
-	log.Println(task)
-	res, err := task.StartCalculation()
+	key, err := cacheKey(task, in)
 	if err != nil {
-		return nil, fmt.Errorf("can't complite calculations due to %v", err)
+		return nil, fmt.Errorf("can't make cache key due to %v", err)
+	}
+	cached, err := s.cache.Get(key)
+	if err != nil && !errors.Is(err, cache.ErrMiss) {
+		log.Printf("can't get cached calculation due to %v", err)
+	}
+	if err == nil {
+		res := &pb.CalculateResponse{}
+		if err = proto.Unmarshal(cached, res); err == nil {
+			return res, nil
+		}
+		log.Printf("can't decode cached calculation due to %v", err)
+	}
+	if progress == nil {
+		// concurrent callers of the same calculation share it
+		return s.flights.do(ctx, key, func(ctx context.Context) (*pb.CalculateResponse, error) {
+			return s.schedule(ctx, in, scheduler, task, key, nil)
+		})
 	}
-	err = s.clientRedis.Set(targetTaskName, res, 5*time.Second).Err()
+	return s.schedule(ctx, in, scheduler, task, key, progress)
+}
+
+// schedule calculates the task and caches complete result by key
+func (s *Service) schedule(ctx context.Context, in *pb.CalculateRequest, scheduler core.Scheduler, task core.Task, key string, progress func(core.Progress)) (*pb.CalculateResponse, error) {
+	log.Println(task)
+	seed := requestSeed(in.Seed)
+	calcCtx, cancel := calculationContext(ctx)
+	defer cancel()
+	result, err := scheduler.Schedule(calcCtx, &task, core.Options{
+		Parameters: core.Parameters(in.GetParameters()),
+		Seed:       seed,
+		Progress:   progress,
+	})
+	if err = calculationError(ctx, calcCtx, err); err != nil {
+		return nil, err
+	}
+	res := scheduleToPb(task, result.Schedule)
+	res.Optimal = result.Optimal
+	res.Strategy = in.GetStrategy()
+	if res.Strategy == "" {
+		res.Strategy = core.AutoStrategy
+	}
+	res.Seed = seed
+	res.AlgorithmVersion = core.AlgorithmVersion
+	res.Partial = result.Partial
+	res.LowerBound = uint64(result.LowerBound)
+	res.Gap = result.Gap()
+	violations, err := task.DeadlineViolations(result.Schedule)
 	if err != nil {
-		log.Printf("can't cash calculation due to %v", err)
+		return nil, fmt.Errorf("can't check deadlines due to %v", err)
+	}
+	if len(violations) != 0 {
+		res.Infeasibility = &pb.InfeasibilityReport{
+			Lateness:   uint64(result.Schedule.Lateness),
+			Violations: make([]*pb.DeadlineViolation, 0, len(violations)),
+		}
+		for _, violation := range violations {
+			res.Infeasibility.Violations = append(res.Infeasibility.Violations, &pb.DeadlineViolation{
+				Work:        string(violation.Work),
+				Deadline:    uint64(violation.Deadline),
+				Finish:      uint64(violation.Finish),
+				Lateness:    uint64(violation.Lateness),
+				Unavoidable: violation.Unavoidable,
+			})
+		}
+	}
+	if explanation, err := task.Explain(result.Schedule); err != nil {
+		log.Printf("can't explain schedule of task %v due to %v", task.Name, err)
+	} else {
+		res.Explanation = explanationToPb(explanation)
+	}
+	if dates, err := task.ScheduleDates(result.Schedule); err != nil {
+		log.Printf("can't calculate dates of task %v due to %v", task.Name, err)
+	} else if dates != nil {
+		res.StartDate = dates.Start
+		res.FinishDate = dates.Finish
+		for _, work := range res.Works {
+			work.StartDate = dates.Works[core.WorkID(work.Work)].Start
+			work.FinishDate = dates.Works[core.WorkID(work.Work)].Finish
+		}
+	}
+	if res.Partial {
+		log.Printf("Partial calculation result: %v", res)
+		return res, nil
+	}
+
+	if encoded, err := proto.Marshal(res); err == nil {
+		if err = s.cache.Set(key, encoded, cacheTTL); err != nil {
+			log.Printf("can't cash calculation due to %v", err)
+		}
 	}
 	log.Printf("Calculation result: %v", res)
-	return &pb.CalculateResponse{Time: res}, nil
+	return res, nil
+}
+
+func (s *Service) Simulate(ctx context.Context, in *pb.SimulateRequest) (*pb.SimulateResponse, error) {
+	strategy := in.GetStrategy()
+	if strategy == "" {
+		strategy = core.PriorityStrategy
+	}
+	scheduler, err := core.GetScheduler(strategy)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	task, err := s.getTask(in.GetTask())
+	if err != nil {
+		return nil, err
+	}
+	if in.GetSamples() > core.MaxSamples {
+		return nil, status.Errorf(codes.InvalidArgument, "too many samples %v, expected at most %v", in.GetSamples(), core.MaxSamples)
+	}
+	samples := int(in.GetSamples())
+	if samples == 0 {
+		samples = 1000
+	}
+	distribution := in.GetDistribution()
+	if distribution == "" {
+		distribution = core.PertDistribution
+	}
+	seed := requestSeed(in.Seed)
+
+	calcCtx, cancel := calculationContext(ctx)
+	defer cancel()
+	simulation, err := task.Simulate(calcCtx, core.SimulationOptions{
+		Samples:      samples,
+		Distribution: distribution,
+		Scheduler:    scheduler,
+		Options: core.Options{
+			Parameters: core.Parameters(in.GetParameters()),
+			Seed:       seed,
+		},
+	})
+	if err = calculationError(ctx, calcCtx, err); err != nil {
+		return nil, err
+	}
+
+	res := &pb.SimulateResponse{
+		P50:              uint64(simulation.Percentile(50)),
+		P80:              uint64(simulation.Percentile(80)),
+		P95:              uint64(simulation.Percentile(95)),
+		Mean:             simulation.Mean(),
+		Samples:          uint64(len(simulation.Makespans)),
+		Distribution:     distribution,
+		Strategy:         strategy,
+		Seed:             seed,
+		AlgorithmVersion: core.AlgorithmVersion,
+		Partial:          simulation.Partial,
+	}
+	for _, bin := range simulation.Histogram() {
+		res.Histogram = append(res.Histogram, &pb.HistogramBin{
+			From:  uint64(bin.From),
+			To:    uint64(bin.To),
+			Count: uint64(bin.Count),
+		})
+	}
+	log.Printf("Simulation result: %v", res)
+	return res, nil
+}
+
+// CalculateProjects schedules several stored tasks together on shared resource pools
+func (s *Service) CalculateProjects(ctx context.Context, in *pb.CalculateProjectsRequest) (*pb.CalculateProjectsResponse, error) {
+	if len(in.GetTasks()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no tasks to calculate")
+	}
+	scheduler, err := core.GetScheduler(in.GetStrategy())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	tasks := make([]core.Task, 0, len(in.GetTasks()))
+	for _, taskName := range in.GetTasks() {
+		task, err := s.getTask(taskName)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	capacity := make(map[core.ResourceID]uint, len(in.GetCapacity()))
+	for pool, amount := range in.GetCapacity() {
+		capacity[core.ResourceID(pool)] = uint(amount)
+	}
+	combined, err := core.CombineTasks(tasks, capacity)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "can't combine tasks: %v", err)
+	}
+	seed := requestSeed(in.Seed)
+
+	calcCtx, cancel := calculationContext(ctx)
+	defer cancel()
+	result, err := scheduler.Schedule(calcCtx, &combined, core.Options{
+		Parameters: core.Parameters(in.GetParameters()),
+		Seed:       seed,
+	})
+	if err = calculationError(ctx, calcCtx, err); err != nil {
+		return nil, err
+	}
+
+	combinedRes := scheduleToPb(combined, result.Schedule)
+	res := &pb.CalculateProjectsResponse{
+		Time:             combinedRes.GetTime(),
+		Projects:         make([]*pb.ProjectSchedule, 0, len(tasks)),
+		Resources:        combinedRes.GetResources(),
+		Optimal:          result.Optimal,
+		Strategy:         in.GetStrategy(),
+		Seed:             seed,
+		AlgorithmVersion: core.AlgorithmVersion,
+		Partial:          result.Partial,
+	}
+	if res.Strategy == "" {
+		res.Strategy = core.AutoStrategy
+	}
+	projects := result.Schedule.SplitProjects()
+	for _, task := range tasks {
+		project := projects[task.Name]
+		projectRes := scheduleToPb(task, core.Schedule{Time: project.Time, Works: project.Works})
+		res.Projects = append(res.Projects, &pb.ProjectSchedule{
+			Task:     task.Name,
+			Time:     uint64(project.Time),
+			Works:    projectRes.GetWorks(),
+			Lateness: uint64(project.Lateness),
+		})
+	}
+	log.Printf("Projects calculation result: %v", res)
+	return res, nil
+}
+
+// ValidateSchedule checks the schedule proposed by user against constraints of the task
+func (s *Service) ValidateSchedule(ctx context.Context, in *pb.ValidateScheduleRequest) (*pb.ValidateScheduleResponse, error) {
+	task, err := s.getTask(in.GetTask())
+	if err != nil {
+		return nil, err
+	}
+	planned := make(map[core.WorkID]core.PlannedWork, len(in.GetWorks()))
+	for _, work := range in.GetWorks() {
+		if _, ok := planned[core.WorkID(work.GetWork())]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "work %v is planned twice", work.GetWork())
+		}
+		planned[core.WorkID(work.GetWork())] = core.PlannedWork{Start: uint(work.GetStart()), Mode: work.GetMode()}
+	}
+	schedule, violations, err := task.ValidateSchedule(planned)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "can't validate schedule: %v", err)
+	}
+
+	res := &pb.ValidateScheduleResponse{
+		Valid:      len(violations) == 0,
+		Time:       uint64(schedule.Time),
+		Violations: make([]*pb.Violation, 0, len(violations)),
+	}
+	for _, violation := range violations {
+		works := make([]string, 0, len(violation.Works))
+		for _, work := range violation.Works {
+			works = append(works, string(work))
+		}
+		res.Violations = append(res.Violations, &pb.Violation{
+			Kind:     violation.Kind,
+			Works:    works,
+			Resource: string(violation.Resource),
+			From:     uint64(violation.From),
+			To:       uint64(violation.To),
+			Used:     uint64(violation.Used),
+			Capacity: uint64(violation.Capacity),
+		})
+	}
+	return res, nil
+}
+
+func (s *Service) CriticalPath(ctx context.Context, in *pb.CriticalPathRequest) (*pb.CriticalPathResponse, error) {
+	task, err := s.getTask(in.GetTask())
+	if err != nil {
+		return nil, err
+	}
+	path, err := task.FindCriticalPath()
+	if err != nil {
+		return nil, fmt.Errorf("can't find critical path due to %v", err)
+	}
+
+	res := &pb.CriticalPathResponse{
+		Time:     uint64(path.Time),
+		Works:    make([]*pb.WorkTiming, 0, len(path.Works)),
+		Critical: make([]string, 0, len(path.Critical)),
+	}
+	for workID, timing := range path.Works {
+		res.Works = append(res.Works, &pb.WorkTiming{
+			Work:           string(workID),
+			EarliestStart:  uint64(timing.EarliestStart),
+			EarliestFinish: uint64(timing.EarliestFinish),
+			LatestStart:    uint64(timing.LatestStart),
+			LatestFinish:   uint64(timing.LatestFinish),
+			TotalFloat:     uint64(timing.TotalFloat),
+		})
+	}
+	sort.Slice(res.Works, func(i, j int) bool {
+		if res.Works[i].EarliestStart != res.Works[j].EarliestStart {
+			return res.Works[i].EarliestStart < res.Works[j].EarliestStart
+		}
+		return res.Works[i].Work < res.Works[j].Work
+	})
+	for _, workID := range path.Critical {
+		res.Critical = append(res.Critical, string(workID))
+	}
+	return res, nil
+}
+
+func explanationToPb(explanation core.Explanation) *pb.Explanation {
+	res := &pb.Explanation{
+		Chain:             make([]*pb.ChainLink, 0, len(explanation.Chain)),
+		Saturated:         make([]*pb.SaturatedPeriod, 0, len(explanation.Saturated)),
+		UnconstrainedTime: uint64(explanation.UnconstrainedTime),
+	}
+	for _, link := range explanation.Chain {
+		res.Chain = append(res.Chain, &pb.ChainLink{
+			Work:     string(link.Work),
+			Start:    uint64(link.Start),
+			Finish:   uint64(link.Finish),
+			Reason:   link.Reason,
+			Cause:    string(link.Cause),
+			Resource: string(link.Resource),
+			Wait:     uint64(link.Wait),
+		})
+	}
+	for _, period := range explanation.Saturated {
+		res.Saturated = append(res.Saturated, &pb.SaturatedPeriod{
+			Resource: string(period.Resource),
+			From:     uint64(period.From),
+			To:       uint64(period.To),
+		})
+	}
+	return res
+}
+
+func scheduleToPb(task core.Task, schedule core.Schedule) *pb.CalculateResponse {
+	res := &pb.CalculateResponse{
+		Time:      uint64(schedule.Time),
+		Works:     make([]*pb.ScheduledWork, 0, len(schedule.Works)),
+		Resources: make([]*pb.ResourceProfile, 0, len(task.Resources)),
+	}
+	for workID, work := range schedule.Works {
+		res.Works = append(res.Works, &pb.ScheduledWork{
+			Work:   string(workID),
+			Start:  uint64(work.Start),
+			Finish: uint64(work.Finish),
+			Mode:   work.Mode,
+		})
+	}
+	sort.Slice(res.Works, func(i, j int) bool {
+		if res.Works[i].Start != res.Works[j].Start {
+			return res.Works[i].Start < res.Works[j].Start
+		}
+		return res.Works[i].Work < res.Works[j].Work
+	})
+	for pool, segments := range schedule.Resources() {
+		profile := &pb.ResourceProfile{
+			Resource: string(pool),
+			Capacity: uint64(task.Resources[pool]),
+			Segments: make([]*pb.ResourceSegment, 0, len(segments)),
+		}
+		for _, segment := range segments {
+			profile.Segments = append(profile.Segments, &pb.ResourceSegment{
+				Start:  uint64(segment.Start),
+				Finish: uint64(segment.Finish),
+				Used:   uint64(segment.Used),
+			})
+		}
+		res.Resources = append(res.Resources, profile)
+	}
+	sort.Slice(res.Resources, func(i, j int) bool {
+		return res.Resources[i].Resource < res.Resources[j].Resource
+	})
+	return res
 }
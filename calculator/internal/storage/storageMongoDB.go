@@ -50,7 +50,12 @@ func (tms *TasksMongoStorage) Get(taskName string) (task core.Task, err error) {
 		return
 	}
 
-	if err = res.Decode(&task); err != nil {
+	raw, err := res.DecodeBytes()
+	if err != nil {
+		err = fmt.Errorf("can't decode res due to %v", err)
+		return
+	}
+	if task, err = decodeTask(raw); err != nil {
 		err = fmt.Errorf("can't decode res due to %v", err)
 		return
 	}
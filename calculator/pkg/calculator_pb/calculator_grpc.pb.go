@@ -23,6 +23,12 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CalculatorClient interface {
 	Calculate(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (*CalculateResponse, error)
+	CalculateStream(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (Calculator_CalculateStreamClient, error)
+	CriticalPath(ctx context.Context, in *CriticalPathRequest, opts ...grpc.CallOption) (*CriticalPathResponse, error)
+	Simulate(ctx context.Context, in *SimulateRequest, opts ...grpc.CallOption) (*SimulateResponse, error)
+	CalculateProjects(ctx context.Context, in *CalculateProjectsRequest, opts ...grpc.CallOption) (*CalculateProjectsResponse, error)
+	ValidateSchedule(ctx context.Context, in *ValidateScheduleRequest, opts ...grpc.CallOption) (*ValidateScheduleResponse, error)
+	CacheStats(ctx context.Context, in *CacheStatsRequest, opts ...grpc.CallOption) (*CacheStatsResponse, error)
 }
 
 type calculatorClient struct {
@@ -42,11 +48,94 @@ func (c *calculatorClient) Calculate(ctx context.Context, in *CalculateRequest,
 	return out, nil
 }
 
+func (c *calculatorClient) CalculateStream(ctx context.Context, in *CalculateRequest, opts ...grpc.CallOption) (Calculator_CalculateStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Calculator_ServiceDesc.Streams[0], "/calculator_pb.Calculator/CalculateStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &calculatorCalculateStreamClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Calculator_CalculateStreamClient interface {
+	Recv() (*CalculateProgress, error)
+	grpc.ClientStream
+}
+
+type calculatorCalculateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *calculatorCalculateStreamClient) Recv() (*CalculateProgress, error) {
+	m := new(CalculateProgress)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *calculatorClient) CriticalPath(ctx context.Context, in *CriticalPathRequest, opts ...grpc.CallOption) (*CriticalPathResponse, error) {
+	out := new(CriticalPathResponse)
+	err := c.cc.Invoke(ctx, "/calculator_pb.Calculator/CriticalPath", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorClient) Simulate(ctx context.Context, in *SimulateRequest, opts ...grpc.CallOption) (*SimulateResponse, error) {
+	out := new(SimulateResponse)
+	err := c.cc.Invoke(ctx, "/calculator_pb.Calculator/Simulate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorClient) CalculateProjects(ctx context.Context, in *CalculateProjectsRequest, opts ...grpc.CallOption) (*CalculateProjectsResponse, error) {
+	out := new(CalculateProjectsResponse)
+	err := c.cc.Invoke(ctx, "/calculator_pb.Calculator/CalculateProjects", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorClient) ValidateSchedule(ctx context.Context, in *ValidateScheduleRequest, opts ...grpc.CallOption) (*ValidateScheduleResponse, error) {
+	out := new(ValidateScheduleResponse)
+	err := c.cc.Invoke(ctx, "/calculator_pb.Calculator/ValidateSchedule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *calculatorClient) CacheStats(ctx context.Context, in *CacheStatsRequest, opts ...grpc.CallOption) (*CacheStatsResponse, error) {
+	out := new(CacheStatsResponse)
+	err := c.cc.Invoke(ctx, "/calculator_pb.Calculator/CacheStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CalculatorServer is the server API for Calculator service.
 // All implementations must embed UnimplementedCalculatorServer
 // for forward compatibility
 type CalculatorServer interface {
 	Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error)
+	CalculateStream(*CalculateRequest, Calculator_CalculateStreamServer) error
+	CriticalPath(context.Context, *CriticalPathRequest) (*CriticalPathResponse, error)
+	Simulate(context.Context, *SimulateRequest) (*SimulateResponse, error)
+	CalculateProjects(context.Context, *CalculateProjectsRequest) (*CalculateProjectsResponse, error)
+	ValidateSchedule(context.Context, *ValidateScheduleRequest) (*ValidateScheduleResponse, error)
+	CacheStats(context.Context, *CacheStatsRequest) (*CacheStatsResponse, error)
 	mustEmbedUnimplementedCalculatorServer()
 }
 
@@ -57,6 +146,24 @@ type UnimplementedCalculatorServer struct {
 func (UnimplementedCalculatorServer) Calculate(context.Context, *CalculateRequest) (*CalculateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Calculate not implemented")
 }
+func (UnimplementedCalculatorServer) CalculateStream(*CalculateRequest, Calculator_CalculateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method CalculateStream not implemented")
+}
+func (UnimplementedCalculatorServer) CriticalPath(context.Context, *CriticalPathRequest) (*CriticalPathResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CriticalPath not implemented")
+}
+func (UnimplementedCalculatorServer) Simulate(context.Context, *SimulateRequest) (*SimulateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Simulate not implemented")
+}
+func (UnimplementedCalculatorServer) CalculateProjects(context.Context, *CalculateProjectsRequest) (*CalculateProjectsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CalculateProjects not implemented")
+}
+func (UnimplementedCalculatorServer) ValidateSchedule(context.Context, *ValidateScheduleRequest) (*ValidateScheduleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateSchedule not implemented")
+}
+func (UnimplementedCalculatorServer) CacheStats(context.Context, *CacheStatsRequest) (*CacheStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CacheStats not implemented")
+}
 func (UnimplementedCalculatorServer) mustEmbedUnimplementedCalculatorServer() {}
 
 // UnsafeCalculatorServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +195,117 @@ func _Calculator_Calculate_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Calculator_CalculateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CalculateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CalculatorServer).CalculateStream(m, &calculatorCalculateStreamServer{stream})
+}
+
+type Calculator_CalculateStreamServer interface {
+	Send(*CalculateProgress) error
+	grpc.ServerStream
+}
+
+type calculatorCalculateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *calculatorCalculateStreamServer) Send(m *CalculateProgress) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Calculator_CriticalPath_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CriticalPathRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).CriticalPath(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator_pb.Calculator/CriticalPath",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).CriticalPath(ctx, req.(*CriticalPathRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calculator_Simulate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimulateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).Simulate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator_pb.Calculator/Simulate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).Simulate(ctx, req.(*SimulateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calculator_CalculateProjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CalculateProjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).CalculateProjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator_pb.Calculator/CalculateProjects",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).CalculateProjects(ctx, req.(*CalculateProjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calculator_ValidateSchedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).ValidateSchedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator_pb.Calculator/ValidateSchedule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).ValidateSchedule(ctx, req.(*ValidateScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Calculator_CacheStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CacheStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CalculatorServer).CacheStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/calculator_pb.Calculator/CacheStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CalculatorServer).CacheStats(ctx, req.(*CacheStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Calculator_ServiceDesc is the grpc.ServiceDesc for Calculator service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,7 +317,33 @@ var Calculator_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Calculate",
 			Handler:    _Calculator_Calculate_Handler,
 		},
+		{
+			MethodName: "CriticalPath",
+			Handler:    _Calculator_CriticalPath_Handler,
+		},
+		{
+			MethodName: "Simulate",
+			Handler:    _Calculator_Simulate_Handler,
+		},
+		{
+			MethodName: "CalculateProjects",
+			Handler:    _Calculator_CalculateProjects_Handler,
+		},
+		{
+			MethodName: "ValidateSchedule",
+			Handler:    _Calculator_ValidateSchedule_Handler,
+		},
+		{
+			MethodName: "CacheStats",
+			Handler:    _Calculator_CacheStats_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CalculateStream",
+			Handler:       _Calculator_CalculateStream_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "calculator.proto",
 }
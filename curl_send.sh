@@ -3,7 +3,7 @@ curl http://localhost:8080/task \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"task_name":"task0", "start_date":"1-1-2022"}'
+    --data '{"task_name":"task0", "start_date":"2022-01-03", "resources":{"workers":10, "cranes":2}, "calendar":{"holidays":["2022-01-07"]}}'
 # sleep 1
 
 curl http://localhost:8080/work/task0 \
@@ -11,7 +11,7 @@ curl http://localhost:8080/work/task0 \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"work_name":"work1", "duration":2, "resources":10}'
+    --data '{"work_name":"work1", "duration":2, "resources":{"workers":10}, "deadline":20}'
 
 # sleep 1
 
@@ -20,7 +20,7 @@ curl http://localhost:8080/work/task0 \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"work_name":"work2", "duration":2, "resources":10}'
+    --data '{"work_name":"work2", "duration":2, "resources":{"workers":10}}'
 
 
 curl http://localhost:8080/work/task0 \
@@ -28,7 +28,7 @@ curl http://localhost:8080/work/task0 \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"work_name":"work3", "duration":2, "resources":10}'
+    --data '{"work_name":"work3", "duration":2, "resources":{"workers":10}}'
 # sleep 1
 
 
@@ -37,35 +37,35 @@ curl http://localhost:8080/work/task0 \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"work_name":"work4", "duration":3, "resources":2}'
+    --data '{"work_name":"work4", "modes":[{"name":"slow", "duration":6, "resources":{"workers":1}}, {"name":"fast", "duration":3, "resources":{"workers":2}}]}'
 
 curl http://localhost:8080/work/task0 \
     -w '\n' \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"work_name":"work5", "duration":4, "resources":1}'
+    --data '{"work_name":"work5", "duration":4, "resources":{"workers":1, "cranes":2}, "estimate":{"optimistic":3, "most_likely":4, "pessimistic":8}}'
 
 curl http://localhost:8080/work/task0 \
     -w '\n' \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"work_name":"work6", "duration":2, "resources":5}'
+    --data '{"work_name":"work6", "duration":2, "resources":{"workers":5}}'
 
 curl http://localhost:8080/work/task0 \
     -w '\n' \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"work_name":"work7", "duration":3, "resources":7}'
+    --data '{"work_name":"work7", "duration":3, "resources":{"workers":7, "cranes":1}}'
 
 curl http://localhost:8080/work/task0 \
     -w '\n' \
     --include \
     --header "Content-Type: appliworkcation/json" \
     --request "POST" \
-    --data '{"work_name":"work8", "duration":5, "resources":9}'
+    --data '{"work_name":"work8", "duration":5, "resources":{"workers":9}}'
 
 
 curl http://localhost:8080/work/task0/work1 \
@@ -108,7 +108,7 @@ curl http://localhost:8080/work/task0/work6 \
     --include \
     --header "Content-Type: application/json" \
     --request "POST" \
-    --data '{"pred":["work8"]}'
+    --data '{"needs":[{"work":"work8", "type":"SS", "lag":2}]}'
 
 curl http://localhost:8080/work/task0/work7 \
     -w '\n' \
@@ -132,3 +132,45 @@ curl http://localhost:8080/calculate/task0\
     --request "GET"
 
 
+curl http://localhost:8080/critical-path/task0\
+    -w '\n' \
+    --request "GET"
+
+curl http://localhost:8080/cache-stats\
+    -w '\n' \
+    --request "GET"
+
+curl "http://localhost:8080/calculate/task0?strategy=genetic&population=100"\
+    -w '\n' \
+    --request "GET"
+
+curl -N "http://localhost:8080/calculate/task0/stream?strategy=annealing"\
+    -w '\n' \
+    --request "GET"
+
+curl "http://localhost:8080/simulate/task0?samples=500&distribution=pert&seed=1"\
+    -w '\n' \
+    --request "GET"
+
+curl "http://localhost:8080/calculate-projects?task=task0&capacity.workers=12&strategy=priority"\
+    -w '\n' \
+    --request "GET"
+
+curl http://localhost:8080/validate/task0 \
+    -w '\n' \
+    --include \
+    --header "Content-Type: application/json" \
+    --request "POST" \
+    --data '{"works":[{"work":"work8", "start":0}, {"work":"work7", "start":5}, {"work":"work6", "start":2}, {"work":"work5", "start":8}, {"work":"work4", "start":8, "mode":"fast"}, {"work":"work3", "start":12}, {"work":"work2", "start":11}, {"work":"work1", "start":14}]}'
+
+curl http://localhost:8080/calculate/task0 \
+    -w '\n' \
+    --header "Content-Type: application/json" \
+    --request "POST" \
+    --data '{"overrides":[{"work":"work8", "duration":3}, {"work":"work1", "remove_dependencies":["work3"]}, {"capacity":{"workers":12}}]}'
+
+curl "http://localhost:8080/calculate?strategy=exact" \
+    -w '\n' \
+    --header "Content-Type: application/json" \
+    --request "POST" \
+    --data '{"definition":{"name":"scenario", "resources":{"workers":4}, "works":[{"name":"a", "duration":2, "resources":{"workers":3}}, {"name":"b", "duration":3, "resources":{"workers":2}, "needs":[{"work":"a", "type":"SS", "lag":1}]}]}}'
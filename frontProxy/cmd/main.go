@@ -8,6 +8,8 @@ import (
 	kafka "github.com/segmentio/kafka-go"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"io"
 	"log"
 	"net"
 	"net/http"
@@ -15,6 +17,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func main() {
@@ -50,12 +53,223 @@ func main() {
 
 	router.GET("/calculate/:task_name", func(ctx *gin.Context) {
 		task := ctx.Param("task_name")
-		r, err := calc.Calculate(ctx, &pb.CalculateRequest{Task: task})
+		req, err := calculateRequest(ctx, task)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		callCtx, cancel, err := calculationContext(ctx)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		defer cancel()
+		r, err := calc.Calculate(callCtx, req)
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not calculate: %v", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, calculationJSON(task, r))
+
+	})
+
+	// what-if calculation, body is {"definition":{...}} with the whole task
+	// or {"overrides":[...]} with changes of the stored task, nothing is saved
+	calculateWhatIf := func(ctx *gin.Context) {
+		task := ctx.Param("task_name")
+		req, err := whatIfRequest(ctx, task)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		callCtx, cancel, err := calculationContext(ctx)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		defer cancel()
+		r, err := calc.Calculate(callCtx, req)
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not calculate: %v", err))
+			return
+		}
+		if task == "" {
+			task = req.GetDefinition().GetName()
+		}
+		ctx.JSON(http.StatusOK, calculationJSON(task, r))
+	}
+	router.POST("/calculate", calculateWhatIf)
+	router.POST("/calculate/:task_name", calculateWhatIf)
+
+	// server-sent events: "progress" on every shorter schedule, "result" or "error" in the end
+	router.GET("/calculate/:task_name/stream", func(ctx *gin.Context) {
+		task := ctx.Param("task_name")
+		req, err := calculateRequest(ctx, task)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		callCtx, cancel, err := calculationContext(ctx)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		defer cancel()
+		stream, err := calc.CalculateStream(callCtx, req)
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not calculate: %v", err))
+			return
+		}
+		ctx.Stream(func(w io.Writer) bool {
+			progress, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					ctx.SSEvent("error", fmt.Sprintf("could not calculate: %v", err))
+				}
+				return false
+			}
+			if r := progress.GetResult(); r != nil {
+				ctx.SSEvent("result", calculationJSON(task, r))
+				return false
+			}
+			ctx.SSEvent("progress", gin.H{
+				"task":        task,
+				"MinimalTime": progress.GetTime(),
+				"Iteration":   progress.GetIteration(),
+				"ElapsedMs":   progress.GetElapsedMs(),
+			})
+			return true
+		})
+	})
+
+	router.GET("/simulate/:task_name", func(ctx *gin.Context) {
+		task := ctx.Param("task_name")
+		req, err := simulateRequest(ctx, task)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		callCtx, cancel, err := calculationContext(ctx)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		defer cancel()
+		r, err := calc.Simulate(callCtx, req)
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not simulate: %v", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"task":         task,
+			"P50":          r.GetP50(),
+			"P80":          r.GetP80(),
+			"P95":          r.GetP95(),
+			"Mean":         r.GetMean(),
+			"Histogram":    histogram(r.GetHistogram()),
+			"Samples":      r.GetSamples(),
+			"Distribution": r.GetDistribution(),
+			"Strategy":     r.GetStrategy(),
+			"Seed":         r.GetSeed(),
+			"Version":      r.GetAlgorithmVersion(),
+			"Partial":      r.GetPartial(),
+		})
+	})
+
+	router.GET("/calculate-projects", func(ctx *gin.Context) {
+		req, err := projectsRequest(ctx)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		callCtx, cancel, err := calculationContext(ctx)
+		if err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, err)
+			return
+		}
+		defer cancel()
+		r, err := calc.CalculateProjects(callCtx, req)
 		if err != nil {
 			ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not calculate: %v", err))
+			return
+		}
+		projects := make([]gin.H, 0, len(r.GetProjects()))
+		for _, project := range r.GetProjects() {
+			projects = append(projects, gin.H{
+				"task":     project.GetTask(),
+				"Time":     project.GetTime(),
+				"Lateness": project.GetLateness(),
+				"Works":    scheduledWorks(project.GetWorks()),
+			})
 		}
-		ctx.JSON(http.StatusOK, gin.H{"task": task, "MinimalTime": r.GetTime()})
+		ctx.JSON(http.StatusOK, gin.H{
+			"MinimalTime": r.GetTime(),
+			"Optimal":     r.GetOptimal(),
+			"Strategy":    r.GetStrategy(),
+			"Seed":        r.GetSeed(),
+			"Version":     r.GetAlgorithmVersion(),
+			"Partial":     r.GetPartial(),
+			"Projects":    projects,
+			"Resources":   resourceProfiles(r.GetResources()),
+		})
+	})
 
+	// body is {"works":[{"work":"work1", "start":0, "mode":"fast"}, ...]}
+	router.POST("/validate/:task_name", func(ctx *gin.Context) {
+		task := ctx.Param("task_name")
+		var body struct {
+			Works []struct {
+				Work  string `json:"work"`
+				Start uint64 `json:"start"`
+				Mode  string `json:"mode"`
+			} `json:"works"`
+		}
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid schedule: %v", err))
+			return
+		}
+		req := &pb.ValidateScheduleRequest{Task: task}
+		for _, work := range body.Works {
+			req.Works = append(req.Works, &pb.PlannedWork{Work: work.Work, Start: work.Start, Mode: work.Mode})
+		}
+		r, err := calc.ValidateSchedule(ctx.Request.Context(), req)
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not validate: %v", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"task":       task,
+			"Valid":      r.GetValid(),
+			"Time":       r.GetTime(),
+			"Violations": violations(r.GetViolations()),
+		})
+	})
+
+	router.GET("/critical-path/:task_name", func(ctx *gin.Context) {
+		task := ctx.Param("task_name")
+		r, err := calc.CriticalPath(ctx.Request.Context(), &pb.CriticalPathRequest{Task: task})
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not find critical path: %v", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"task":     task,
+			"Time":     r.GetTime(),
+			"Works":    workTimings(r.GetWorks()),
+			"Critical": r.GetCritical(),
+		})
+	})
+
+	router.GET("/cache-stats", func(ctx *gin.Context) {
+		r, err := calc.CacheStats(ctx.Request.Context(), &pb.CacheStatsRequest{})
+		if err != nil {
+			ctx.AbortWithError(http.StatusInternalServerError, fmt.Errorf("could not get cache stats: %v", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{
+			"Hits":   r.GetHits(),
+			"Misses": r.GetMisses(),
+		})
 	})
 
 	err = router.Run(":8080")
@@ -63,6 +277,373 @@ func main() {
 		log.Fatalln(err)
 	}
 }
+
+// calculationContext is cancelled when the client goes away, ?timeout= query parameter
+// limits time of the calculation, the best result found by then is returned
+func calculationContext(ctx *gin.Context) (context.Context, context.CancelFunc, error) {
+	timeout, ok := ctx.GetQuery("timeout")
+	if !ok {
+		callCtx, cancel := context.WithCancel(ctx.Request.Context())
+		return callCtx, cancel, nil
+	}
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timeout %q: %v", timeout, err)
+	}
+	callCtx, cancel := context.WithTimeout(ctx.Request.Context(), duration)
+	return callCtx, cancel, nil
+}
+
+// calculateRequest takes strategy and seed from ?strategy= and ?seed= query parameters,
+// all other query parameters are passed to the strategy
+func calculateRequest(ctx *gin.Context, task string) (*pb.CalculateRequest, error) {
+	seed, err := querySeed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CalculateRequest{
+		Task:       task,
+		Strategy:   ctx.Query("strategy"),
+		Parameters: strategyParameters(ctx, "strategy", "seed", "timeout"),
+		Seed:       seed,
+	}, nil
+}
+
+// whatIfRequest is like calculateRequest with task definition or overrides from the body
+func whatIfRequest(ctx *gin.Context, task string) (*pb.CalculateRequest, error) {
+	req, err := calculateRequest(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read body: %v", err)
+	}
+	body := &pb.CalculateRequest{}
+	if err = protojson.Unmarshal(data, body); err != nil {
+		return nil, fmt.Errorf("invalid body: %v", err)
+	}
+	req.Definition = body.GetDefinition()
+	req.Overrides = body.GetOverrides()
+	if task == "" && req.Definition == nil {
+		return nil, fmt.Errorf("expected task definition")
+	}
+	return req, nil
+}
+
+// the calculator doesn't simulate more samples
+const maxSamples = 100 * 1000
+
+// simulateRequest is like calculateRequest with ?samples= and ?distribution= of durations
+func simulateRequest(ctx *gin.Context, task string) (*pb.SimulateRequest, error) {
+	seed, err := querySeed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.SimulateRequest{
+		Task:         task,
+		Distribution: ctx.Query("distribution"),
+		Strategy:     ctx.Query("strategy"),
+		Parameters:   strategyParameters(ctx, "strategy", "seed", "timeout", "samples", "distribution"),
+		Seed:         seed,
+	}
+	if samples, ok := ctx.GetQuery("samples"); ok {
+		req.Samples, err = strconv.ParseUint(samples, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid samples %q: %v", samples, err)
+		}
+		if req.Samples > maxSamples {
+			return nil, fmt.Errorf("too many samples %v, expected at most %v", req.Samples, maxSamples)
+		}
+	}
+	return req, nil
+}
+
+// projectsRequest takes names of the tasks from ?task= query parameters and shared
+// capacity of a pool from ?capacity.<pool>=, other parameters are like in calculateRequest
+func projectsRequest(ctx *gin.Context) (*pb.CalculateProjectsRequest, error) {
+	seed, err := querySeed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.CalculateProjectsRequest{
+		Tasks:      ctx.QueryArray("task"),
+		Capacity:   make(map[string]uint64),
+		Strategy:   ctx.Query("strategy"),
+		Parameters: strategyParameters(ctx, "strategy", "seed", "timeout", "task"),
+		Seed:       seed,
+	}
+	if len(req.Tasks) == 0 {
+		return nil, fmt.Errorf("expected at least one ?task= parameter")
+	}
+	for name, value := range req.Parameters {
+		if !strings.HasPrefix(name, "capacity.") {
+			continue
+		}
+		pool := strings.TrimPrefix(name, "capacity.")
+		req.Capacity[pool], err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid capacity of %v %q: %v", pool, value, err)
+		}
+		delete(req.Parameters, name)
+	}
+	return req, nil
+}
+
+func querySeed(ctx *gin.Context) (*int64, error) {
+	seed, ok := ctx.GetQuery("seed")
+	if !ok {
+		return nil, nil
+	}
+	value, err := strconv.ParseInt(seed, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed %q: %v", seed, err)
+	}
+	return &value, nil
+}
+
+// strategyParameters returns all query parameters except the reserved ones
+func strategyParameters(ctx *gin.Context, reserved ...string) map[string]string {
+	parameters := make(map[string]string)
+	for name, values := range ctx.Request.URL.Query() {
+		if len(values) > 0 {
+			parameters[name] = values[0]
+		}
+	}
+	for _, name := range reserved {
+		delete(parameters, name)
+	}
+	return parameters
+}
+
+func calculationJSON(task string, r *pb.CalculateResponse) gin.H {
+	return gin.H{
+		"task":        task,
+		"MinimalTime": r.GetTime(),
+		"Optimal":     r.GetOptimal(),
+		"Strategy":    r.GetStrategy(),
+		"Seed":        r.GetSeed(),
+		"Version":     r.GetAlgorithmVersion(),
+		"Partial":     r.GetPartial(),
+		"LowerBound":  r.GetLowerBound(),
+		"Gap":         r.GetGap(),
+		"StartDate":   r.GetStartDate(),
+		"FinishDate":  r.GetFinishDate(),
+		"Works":       scheduledWorks(r.GetWorks()),
+		"Resources":   resourceProfiles(r.GetResources()),
+		"Infeasible":  infeasibility(r.GetInfeasibility()),
+		"Explanation": explanation(r.GetExplanation()),
+	}
+}
+
+type scheduledWork struct {
+	Work       string `json:"work"`
+	Start      uint64 `json:"start"`
+	Finish     uint64 `json:"finish"`
+	StartDate  string `json:"start_date,omitempty"`
+	FinishDate string `json:"finish_date,omitempty"`
+	Mode       string `json:"mode,omitempty"`
+}
+
+func scheduledWorks(works []*pb.ScheduledWork) []scheduledWork {
+	res := make([]scheduledWork, 0, len(works))
+	for _, work := range works {
+		res = append(res, scheduledWork{
+			Work:       work.GetWork(),
+			Start:      work.GetStart(),
+			Finish:     work.GetFinish(),
+			StartDate:  work.GetStartDate(),
+			FinishDate: work.GetFinishDate(),
+			Mode:       work.GetMode(),
+		})
+	}
+	return res
+}
+
+type resourceSegment struct {
+	Start  uint64 `json:"start"`
+	Finish uint64 `json:"finish"`
+	Used   uint64 `json:"used"`
+}
+
+type resourceProfile struct {
+	Capacity uint64            `json:"capacity"`
+	Segments []resourceSegment `json:"segments"`
+}
+
+func resourceProfiles(profiles []*pb.ResourceProfile) map[string]resourceProfile {
+	res := make(map[string]resourceProfile, len(profiles))
+	for _, profile := range profiles {
+		segments := make([]resourceSegment, 0, len(profile.GetSegments()))
+		for _, segment := range profile.GetSegments() {
+			segments = append(segments, resourceSegment{
+				Start:  segment.GetStart(),
+				Finish: segment.GetFinish(),
+				Used:   segment.GetUsed(),
+			})
+		}
+		res[profile.GetResource()] = resourceProfile{
+			Capacity: profile.GetCapacity(),
+			Segments: segments,
+		}
+	}
+	return res
+}
+
+type deadlineViolation struct {
+	Work        string `json:"work"`
+	Deadline    uint64 `json:"deadline"`
+	Finish      uint64 `json:"finish"`
+	Lateness    uint64 `json:"lateness"`
+	Unavoidable bool   `json:"unavoidable"`
+}
+
+type infeasibilityReport struct {
+	Lateness   uint64              `json:"lateness"`
+	Violations []deadlineViolation `json:"violations"`
+}
+
+// infeasibility returns nil when all deadlines are met
+func infeasibility(report *pb.InfeasibilityReport) *infeasibilityReport {
+	if report == nil {
+		return nil
+	}
+	res := &infeasibilityReport{
+		Lateness:   report.GetLateness(),
+		Violations: make([]deadlineViolation, 0, len(report.GetViolations())),
+	}
+	for _, violation := range report.GetViolations() {
+		res.Violations = append(res.Violations, deadlineViolation{
+			Work:        violation.GetWork(),
+			Deadline:    violation.GetDeadline(),
+			Finish:      violation.GetFinish(),
+			Lateness:    violation.GetLateness(),
+			Unavoidable: violation.GetUnavoidable(),
+		})
+	}
+	return res
+}
+
+type chainLink struct {
+	Work     string `json:"work"`
+	Start    uint64 `json:"start"`
+	Finish   uint64 `json:"finish"`
+	Reason   string `json:"reason"`
+	Cause    string `json:"cause,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	Wait     uint64 `json:"wait,omitempty"`
+}
+
+type saturatedPeriod struct {
+	Resource string `json:"resource"`
+	From     uint64 `json:"from"`
+	To       uint64 `json:"to"`
+}
+
+type bottleneckExplanation struct {
+	Chain             []chainLink       `json:"chain"`
+	Saturated         []saturatedPeriod `json:"saturated"`
+	UnconstrainedTime uint64            `json:"unconstrained_time"`
+}
+
+func explanation(e *pb.Explanation) *bottleneckExplanation {
+	if e == nil {
+		return nil
+	}
+	res := &bottleneckExplanation{
+		Chain:             make([]chainLink, 0, len(e.GetChain())),
+		Saturated:         make([]saturatedPeriod, 0, len(e.GetSaturated())),
+		UnconstrainedTime: e.GetUnconstrainedTime(),
+	}
+	for _, link := range e.GetChain() {
+		res.Chain = append(res.Chain, chainLink{
+			Work:     link.GetWork(),
+			Start:    link.GetStart(),
+			Finish:   link.GetFinish(),
+			Reason:   link.GetReason(),
+			Cause:    link.GetCause(),
+			Resource: link.GetResource(),
+			Wait:     link.GetWait(),
+		})
+	}
+	for _, period := range e.GetSaturated() {
+		res.Saturated = append(res.Saturated, saturatedPeriod{
+			Resource: period.GetResource(),
+			From:     period.GetFrom(),
+			To:       period.GetTo(),
+		})
+	}
+	return res
+}
+
+type histogramBin struct {
+	From  uint64 `json:"from"`
+	To    uint64 `json:"to"`
+	Count uint64 `json:"count"`
+}
+
+func histogram(bins []*pb.HistogramBin) []histogramBin {
+	res := make([]histogramBin, 0, len(bins))
+	for _, bin := range bins {
+		res = append(res, histogramBin{
+			From:  bin.GetFrom(),
+			To:    bin.GetTo(),
+			Count: bin.GetCount(),
+		})
+	}
+	return res
+}
+
+type violation struct {
+	Kind     string   `json:"kind"`
+	Works    []string `json:"works"`
+	Resource string   `json:"resource,omitempty"`
+	From     uint64   `json:"from"`
+	To       uint64   `json:"to"`
+	Used     uint64   `json:"used,omitempty"`
+	Capacity uint64   `json:"capacity,omitempty"`
+}
+
+func violations(list []*pb.Violation) []violation {
+	res := make([]violation, 0, len(list))
+	for _, v := range list {
+		res = append(res, violation{
+			Kind:     v.GetKind(),
+			Works:    v.GetWorks(),
+			Resource: v.GetResource(),
+			From:     v.GetFrom(),
+			To:       v.GetTo(),
+			Used:     v.GetUsed(),
+			Capacity: v.GetCapacity(),
+		})
+	}
+	return res
+}
+
+type workTiming struct {
+	Work           string `json:"work"`
+	EarliestStart  uint64 `json:"earliest_start"`
+	EarliestFinish uint64 `json:"earliest_finish"`
+	LatestStart    uint64 `json:"latest_start"`
+	LatestFinish   uint64 `json:"latest_finish"`
+	TotalFloat     uint64 `json:"total_float"`
+}
+
+func workTimings(works []*pb.WorkTiming) []workTiming {
+	res := make([]workTiming, 0, len(works))
+	for _, work := range works {
+		res = append(res, workTiming{
+			Work:           work.GetWork(),
+			EarliestStart:  work.GetEarliestStart(),
+			EarliestFinish: work.GetEarliestFinish(),
+			LatestStart:    work.GetLatestStart(),
+			LatestFinish:   work.GetLatestFinish(),
+			TotalFloat:     work.GetTotalFloat(),
+		})
+	}
+	return res
+}
+
 func getEnvs(names []string) (map[string]string, error) {
 	m := map[string]string{}
 	for _, name := range names {
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const dayLayout = "2006-01-02"
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", dayLayout}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Calendar tells when the works of a task are done. A working day has UnitsPerDay
+// time units of length Unit, counted from the time of the task start date.
+// Empty Unit means that one time unit is a whole working day,
+// empty WorkingDays mean days from monday to friday.
+type Calendar struct {
+	WorkingDays []string `json:"working_days" bson:"working_days"`
+	Holidays    []string `json:"holidays" bson:"holidays"`
+	Unit        string   `json:"unit" bson:"unit"`
+	UnitsPerDay uint     `json:"units_per_day" bson:"units_per_day"`
+}
+
+func checkStartDate(value string) error {
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("can't parse date %q, expected one of formats: %v", value, strings.Join(dateLayouts, ", "))
+}
+
+func (calendar *Calendar) check() error {
+	for _, name := range calendar.WorkingDays {
+		if _, ok := weekdays[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("unknown weekday %q", name)
+		}
+	}
+	for _, holiday := range calendar.Holidays {
+		if _, err := time.Parse(dayLayout, holiday); err != nil {
+			return fmt.Errorf("can't parse holiday %q, expected format %v", holiday, dayLayout)
+		}
+	}
+	unit := 24 * time.Hour
+	if calendar.Unit != "" {
+		var err error
+		unit, err = time.ParseDuration(calendar.Unit)
+		if err != nil || unit <= 0 {
+			return fmt.Errorf("invalid time unit %q", calendar.Unit)
+		}
+	}
+	if calendar.UnitsPerDay != 0 && unit*time.Duration(calendar.UnitsPerDay) > 24*time.Hour {
+		return fmt.Errorf("%v units of %v don't fit in a day", calendar.UnitsPerDay, unit)
+	}
+	return nil
+}
+
+func ChangeCalendar(tasks tasksStorage, targetTaskName string, calendar Calendar) error {
+	task, err := tasks.Get(targetTaskName)
+	if err != nil {
+		return fmt.Errorf("unknown task name : %v ", targetTaskName)
+	}
+	if err = calendar.check(); err != nil {
+		return err
+	}
+
+	task.Calendar = &calendar
+	return tasks.Set(targetTaskName, task)
+}
@@ -2,21 +2,62 @@ package core
 
 import (
 	"fmt"
+	"strings"
 )
 
+// resource pool of tasks created without explicit ones
+const DefaultResource ResourceID = "workers"
+const DefaultCapacity = 10
+
 type WorkID string
+type ResourceID string
 
 type Task struct {
-	Name      string          `json:"task_name" bson:"_id"`
-	StartDate string          `json:"start_date" bson:"start_date"`
-	Works     map[WorkID]Work `json:"works" bson:"works"`
+	Name      string              `json:"task_name" bson:"_id"`
+	StartDate string              `json:"start_date" bson:"start_date"`
+	Calendar  *Calendar           `json:"calendar" bson:"calendar"`
+	Resources map[ResourceID]uint `json:"resources" bson:"resources"`
+	Works     map[WorkID]Work     `json:"works" bson:"works"`
 }
 
 type Work struct {
-	Name              WorkID              `json:"work_name" bson:"work_name"`
-	Duration          uint                `json:"duration" bson:"duration"`
-	ResourceNeeds     uint                `json:"resources" bson:"resource_needs"`
-	WorksNeedToBeDone map[WorkID]struct{} `json:"works_need_to_be_done" bson:"works_need_to_be_done"`
+	Name              WorkID                `json:"work_name" bson:"work_name"`
+	Duration          uint                  `json:"duration" bson:"duration"`
+	ResourceNeeds     map[ResourceID]uint   `json:"resources" bson:"resource_needs"`
+	WorksNeedToBeDone map[WorkID]Dependency `json:"works_need_to_be_done" bson:"works_need_to_be_done"`
+	// when modes are set Duration and ResourceNeeds are ignored
+	Modes []Mode `json:"modes,omitempty" bson:"modes,omitempty"`
+	// the work never starts before earliest start and should finish by deadline
+	EarliestStart *uint `json:"earliest_start,omitempty" bson:"earliest_start,omitempty"`
+	Deadline      *uint `json:"deadline,omitempty" bson:"deadline,omitempty"`
+	// uncertain duration used by simulation instead of Duration
+	Estimate *Estimate `json:"estimate,omitempty" bson:"estimate,omitempty"`
+}
+
+// Estimate is three-point estimate of a work duration
+type Estimate struct {
+	Optimistic  uint `json:"optimistic" bson:"optimistic"`
+	MostLikely  uint `json:"most_likely" bson:"most_likely"`
+	Pessimistic uint `json:"pessimistic" bson:"pessimistic"`
+}
+
+// Mode is one of the ways to do a work, the calculator chooses one of them
+type Mode struct {
+	Name          string              `json:"name" bson:"name"`
+	Duration      uint                `json:"duration" bson:"duration"`
+	ResourceNeeds map[ResourceID]uint `json:"resources" bson:"resource_needs"`
+}
+
+// needs returns resource needs of every work mode
+func (work Work) needs() []map[ResourceID]uint {
+	if len(work.Modes) == 0 {
+		return []map[ResourceID]uint{work.ResourceNeeds}
+	}
+	needs := make([]map[ResourceID]uint, 0, len(work.Modes))
+	for _, mode := range work.Modes {
+		needs = append(needs, mode.ResourceNeeds)
+	}
+	return needs
 }
 
 type tasksStorage interface {
@@ -32,6 +73,24 @@ func CreateTask(tasks tasksStorage, task Task) error {
 	if _, err := tasks.Get(task.Name); err == nil {
 		return fmt.Errorf("task: %v already exists", task.Name)
 	}
+	if len(task.Resources) == 0 {
+		task.Resources = map[ResourceID]uint{DefaultResource: DefaultCapacity}
+	}
+	for resource, capacity := range task.Resources {
+		if capacity == 0 {
+			return fmt.Errorf("capacity of resource %v must be positive", resource)
+		}
+	}
+	if task.StartDate != "" {
+		if err := checkStartDate(task.StartDate); err != nil {
+			return err
+		}
+	}
+	if task.Calendar != nil {
+		if err := task.Calendar.check(); err != nil {
+			return err
+		}
+	}
 	tasks.Set(task.Name, task)
 	return nil
 }
@@ -61,6 +120,9 @@ func ChangeStartDate(tasks tasksStorage, targetTaskName string, newDate string)
 	if err != nil {
 		return fmt.Errorf("unknown task name : %v ", targetTaskName)
 	}
+	if err = checkStartDate(newDate); err != nil {
+		return err
+	}
 
 	task.StartDate = newDate
 	tasks.Set(targetTaskName, task)
@@ -68,6 +130,56 @@ func ChangeStartDate(tasks tasksStorage, targetTaskName string, newDate string)
 	return nil
 }
 
+// ChangeResources sets capacities of the given resource pools,
+// zero capacity removes the pool
+func ChangeResources(tasks tasksStorage, targetTaskName string, resources map[ResourceID]uint) error {
+	task, err := tasks.Get(targetTaskName)
+	if err != nil {
+		return fmt.Errorf("unknown task name : %v ", targetTaskName)
+	}
+	if task.Resources == nil {
+		task.Resources = make(map[ResourceID]uint)
+	}
+
+	for resource, capacity := range resources {
+		for workId, work := range task.Works {
+			for _, needs := range work.needs() {
+				if need := needs[resource]; need > capacity {
+					return fmt.Errorf("work %v needs %v of %v, more than capacity %v", workId, need, resource, capacity)
+				}
+			}
+		}
+		if capacity == 0 {
+			delete(task.Resources, resource)
+		} else {
+			task.Resources[resource] = capacity
+		}
+	}
+	return tasks.Set(targetTaskName, task)
+}
+
+func (task *Task) checkResourceNeeds(work Work) error {
+	names := make(map[string]struct{}, len(work.Modes))
+	for _, mode := range work.Modes {
+		if _, ok := names[mode.Name]; ok {
+			return fmt.Errorf("work %v has several modes named %q", work.Name, mode.Name)
+		}
+		names[mode.Name] = struct{}{}
+	}
+	for _, needs := range work.needs() {
+		for resource, need := range needs {
+			capacity, ok := task.Resources[resource]
+			if !ok {
+				return fmt.Errorf("unknown resource %v for work %v", resource, work.Name)
+			}
+			if need > capacity {
+				return fmt.Errorf("work %v needs %v of %v, more than capacity %v", work.Name, need, resource, capacity)
+			}
+		}
+	}
+	return nil
+}
+
 func DeleteTask(tasks tasksStorage, targetTaskName string) error {
 	_, err := tasks.Get(targetTaskName)
 	if err != nil {
@@ -85,16 +197,25 @@ func AddWorkToTask(tasks tasksStorage, targetTaskName string, work Work) error {
 	if _, ok := task.Works[work.Name]; ok {
 		return fmt.Errorf("work ulready exists")
 	}
-	work.WorksNeedToBeDone = make(map[WorkID]struct{})
+	if err = task.checkResourceNeeds(work); err != nil {
+		return err
+	}
+	if e := work.Estimate; e != nil && (e.Optimistic > e.MostLikely || e.MostLikely > e.Pessimistic) {
+		return fmt.Errorf("estimate of work %v should be optimistic <= most likely <= pessimistic", work.Name)
+	}
+	work.WorksNeedToBeDone = make(map[WorkID]Dependency)
 	task.Works[work.Name] = work
 
 	return tasks.Set(targetTaskName, task)
 }
 
-func AddNeedsForWork(tasks tasksStorage, targetTaskName string, targetWorkId WorkID, neededWorkId WorkID) error {
+func AddNeedsForWork(tasks tasksStorage, targetTaskName string, targetWorkId WorkID, neededWorkId WorkID, dependency Dependency) error {
 	if targetWorkId == neededWorkId {
 		return fmt.Errorf("target work and needed work is the same: %v", targetWorkId)
 	}
+	if err := dependency.check(); err != nil {
+		return err
+	}
 	task, err := tasks.Get(targetTaskName)
 	if err != nil {
 		return fmt.Errorf("unknown task name : %v ", targetTaskName)
@@ -110,13 +231,49 @@ func AddNeedsForWork(tasks tasksStorage, targetTaskName string, targetWorkId Wor
 		return fmt.Errorf("unknow work name : %v", neededWorkId)
 	}
 
-	targetWork.WorksNeedToBeDone[neededWorkId] = struct{}{}
+	if path := task.findNeedsPath(neededWorkId, targetWorkId); path != nil {
+		cycle := append([]WorkID{targetWorkId}, path...)
+		return fmt.Errorf("work %v can't need %v due to dependency cycle: %v", targetWorkId, neededWorkId, formatPath(cycle))
+	}
+
+	targetWork.WorksNeedToBeDone[neededWorkId] = dependency
 
 	task.Works[targetWorkId] = targetWork
 
 	return tasks.Set(targetTaskName, task)
 }
 
+// findNeedsPath returns chain of works from `from` to `to` where every work
+// needs the next one, or nil if `from` doesn't depend on `to`
+func (task *Task) findNeedsPath(from WorkID, to WorkID) []WorkID {
+	visited := make(map[WorkID]struct{})
+	var visit func(id WorkID) []WorkID
+	visit = func(id WorkID) []WorkID {
+		if id == to {
+			return []WorkID{id}
+		}
+		if _, ok := visited[id]; ok {
+			return nil
+		}
+		visited[id] = struct{}{}
+		for pred := range task.Works[id].WorksNeedToBeDone {
+			if path := visit(pred); path != nil {
+				return append([]WorkID{id}, path...)
+			}
+		}
+		return nil
+	}
+	return visit(from)
+}
+
+func formatPath(path []WorkID) string {
+	names := make([]string, len(path))
+	for i, id := range path {
+		names[i] = string(id)
+	}
+	return strings.Join(names, " -> ")
+}
+
 func DeleteWork(tasks tasksStorage, targetTaskName string, targetWorkId WorkID) error {
 	task, err := tasks.Get(targetTaskName)
 	if err != nil {
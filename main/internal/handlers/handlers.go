@@ -27,7 +27,8 @@ func HandleTaskAccess(tasks tasksStorage) func(c *gin.Context) {
 	}
 }
 
-// post /task  json:{"order_name":"", "start_date":""}
+// post /task  json:{"order_name":"", "start_date":"2006-01-02", "resources":{"workers":10},
+// "calendar":{"working_days":["monday"], "holidays":["2006-01-02"], "unit":"1h", "units_per_day":8}}
 func HandleTaskCreation(tasks tasksStorage) func(c *gin.Context) {
 
 	return func(c *gin.Context) {
@@ -63,6 +64,22 @@ func HandleTaskUpdate(tasks tasksStorage) func(c *gin.Context) {
 			}
 		}
 
+		if task.Calendar != nil {
+			err = core.ChangeCalendar(tasks, targetTaskName, *task.Calendar)
+			if err != nil {
+				c.Error(err)
+				c.Data(http.StatusConflict, "application/json", []byte(fmt.Sprintf("error: %v\n", err)))
+			}
+		}
+
+		if len(task.Resources) != 0 {
+			err = core.ChangeResources(tasks, targetTaskName, task.Resources)
+			if err != nil {
+				c.Error(err)
+				c.Data(http.StatusConflict, "application/json", []byte(fmt.Sprintf("error: %v\n", err)))
+			}
+		}
+
 		if task.Name != "" {
 			err = core.RenameTask(tasks, targetTaskName, task.Name)
 			if err != nil {
@@ -113,7 +130,9 @@ func HandleWorkAccess(tasks tasksStorage) func(c *gin.Context) {
 	}
 }
 
-//post /work/:task_name json:{"task":"", "duration":0, "resources":0}
+//post /work/:task_name json:{"task":"", "duration":0, "resources":{"workers":0},
+// "modes":[{"name":"fast", "duration":0, "resources":{"workers":0}}], "earliest_start":0, "deadline":0,
+// "estimate":{"optimistic":0, "most_likely":0, "pessimistic":0}}
 func HandleWorkCreation(tasks tasksStorage) func(c *gin.Context) {
 	return func(context *gin.Context) {
 		taskName := context.Param("task_name")
@@ -131,11 +150,17 @@ func HandleWorkCreation(tasks tasksStorage) func(c *gin.Context) {
 	}
 }
 
-// post /work/:task_name/:work_name
+// post /work/:task_name/:work_name json:{"pred":["work1"], "needs":[{"work":"work2", "type":"SS", "lag":-1}]}
+// works from pred are needed finish-to-start without lag, type is one of FS, SS, FF, SF
 
 func HandleWorkNeedsSetup(tasks tasksStorage) func(c *gin.Context) {
+	type Need struct {
+		Work string `json:"work"`
+		core.Dependency
+	}
 	type Needs struct {
-		Work []string `json:"pred"`
+		Work  []string `json:"pred"`
+		Needs []Need   `json:"needs"`
 	}
 	return func(context *gin.Context) {
 		taskName := context.Param("task_name")
@@ -145,7 +170,10 @@ func HandleWorkNeedsSetup(tasks tasksStorage) func(c *gin.Context) {
 		context.Bind(&needs)
 
 		for _, work := range needs.Work {
-			err := core.AddNeedsForWork(tasks, taskName, workName, core.WorkID(work))
+			needs.Needs = append(needs.Needs, Need{Work: work})
+		}
+		for _, need := range needs.Needs {
+			err := core.AddNeedsForWork(tasks, taskName, workName, core.WorkID(need.Work), need.Dependency)
 
 			if err != nil {
 				context.Error(err)
@@ -154,7 +182,7 @@ func HandleWorkNeedsSetup(tasks tasksStorage) func(c *gin.Context) {
 
 		}
 		if len(context.Errors) == 0 {
-			context.JSON(http.StatusOK, fmt.Sprintf("needs %v for work %v  succesfuly added", needs.Work, workName))
+			context.JSON(http.StatusOK, fmt.Sprintf("needs %v for work %v  succesfuly added", needs.Needs, workName))
 		}
 
 	}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"main/internal/core"
+)
+
+// decodeTask decodes the stored task, tasks stored before resource pools
+// have one number of needed resources per work and optional capacity,
+// they are converted to the default pool
+func decodeTask(raw bson.Raw) (task core.Task, err error) {
+	var doc bson.M
+	if err = bson.Unmarshal(raw, &doc); err != nil {
+		return task, err
+	}
+	if doc["resources"] == nil {
+		capacity, ok := legacyAmount(doc["capacity"])
+		if !ok || capacity == 0 {
+			capacity = core.DefaultCapacity
+		}
+		doc["resources"] = bson.M{string(core.DefaultResource): capacity}
+	}
+	works, _ := doc["works"].(bson.M)
+	for _, w := range works {
+		work, ok := w.(bson.M)
+		if !ok {
+			continue
+		}
+		if need, ok := legacyAmount(work["resource_needs"]); ok {
+			work["resource_needs"] = bson.M{string(core.DefaultResource): need}
+		}
+	}
+	raw, err = bson.Marshal(doc)
+	if err != nil {
+		return task, err
+	}
+	err = bson.Unmarshal(raw, &task)
+	return task, err
+}
+
+func legacyAmount(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	}
+	return 0, false
+}